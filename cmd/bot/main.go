@@ -8,18 +8,27 @@ import (
 	"lappbot/internal/config"
 	"lappbot/internal/modules/antiflood"
 	"lappbot/internal/modules/antiraid"
+	"lappbot/internal/modules/api"
 	"lappbot/internal/modules/captcha"
+	"lappbot/internal/modules/chats"
 	"lappbot/internal/modules/clean"
 	"lappbot/internal/modules/connections"
 	"lappbot/internal/modules/cursed"
+	"lappbot/internal/modules/dashboard"
 	"lappbot/internal/modules/filters"
 	"lappbot/internal/modules/greeting"
+	"lappbot/internal/modules/joinrequest"
 	"lappbot/internal/modules/logging"
 	"lappbot/internal/modules/moderation"
 	"lappbot/internal/modules/notes"
 	"lappbot/internal/modules/purge"
+	"lappbot/internal/modules/relay"
+	"lappbot/internal/modules/rules"
+	"lappbot/internal/modules/settings"
+	"lappbot/internal/modules/setup"
 	"lappbot/internal/modules/topics"
 	"lappbot/internal/modules/utility"
+	"lappbot/internal/modules/webhooks"
 	"lappbot/internal/store"
 )
 
@@ -45,7 +54,8 @@ func main() {
 	logger.Register()
 
 	captcha.New(b, st, logger).Register()
-	filters.New(b, st, logger).Register()
+	filtersModule := filters.New(b, st, logger)
+	filtersModule.Register()
 	antiflood.New(b, st, logger).Register()
 	antiraid.New(b, st, logger).Register()
 	connections.New(b, st, logger).Register()
@@ -53,10 +63,20 @@ func main() {
 	cursed.New(b, cfg, logger).Register()
 	greeting.New(b, st, logger).Register()
 	purge.New(b, st, logger).Register()
-	moderation.New(b, st, logger).Register()
+	moderationModule := moderation.New(b, st, logger)
+	moderationModule.Register()
 	notes.New(b, st, logger).Register()
 	topics.New(b, cfg, logger).Register()
 	clean.New(b, st).Register()
+	chats.New(b, st, logger, filtersModule, moderationModule).Register()
+	joinrequest.New(b, st, logger).Register()
+	rules.New(b, st, logger).Register()
+	setup.New(b).Register()
+	settings.New(b, st, logger).Register()
+	dashboard.New(b, st, logger, filtersModule, moderationModule).Register()
+	api.New(b, st, logger, filtersModule, moderationModule).Register()
+	webhooks.New(b, st, logger).Register()
+	relay.New(b, st, logger).Register()
 
 	if cfg.UseWebhook {
 		b.StartWebhook()
@@ -10,6 +10,7 @@ require (
 	github.com/rs/zerolog v1.34.0
 	github.com/valkey-io/valkey-go v1.0.71
 	github.com/valyala/fasthttp v1.69.0
+	golang.org/x/time v0.14.0
 )
 
 require (
@@ -18,7 +19,6 @@ require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	golang.org/x/time v0.14.0 // indirect
 )
 
 require github.com/matoous/go-nanoid/v2 v2.1.0 // direct
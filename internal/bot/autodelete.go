@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const deleteQueue = "autodelete"
+
+func (b *Bot) DeleteAfter(chatID, messageID int64, d time.Duration) {
+	member := strconv.FormatInt(chatID, 10) + ":" + strconv.FormatInt(messageID, 10)
+	b.Schedule(deleteQueue, member, time.Now().Add(d))
+}
+
+func (b *Bot) runDeleteQueue() {
+	b.RunQueue(deleteQueue, 5*time.Second, func(member string) {
+		chatStr, msgStr, ok := strings.Cut(member, ":")
+		if !ok {
+			return
+		}
+		chatID, _ := strconv.ParseInt(chatStr, 10, 64)
+		msgID, _ := strconv.ParseInt(msgStr, 10, 64)
+		b.Raw("deleteMessage", map[string]any{
+			"chat_id":    chatID,
+			"message_id": msgID,
+		})
+	})
+}
+
+// DeleteMessages deletes in batches of 100 and returns how many IDs were in
+// batches Telegram accepted.
+func (b *Bot) DeleteMessages(chatID int64, messageIDs []int64) int {
+	deleted := 0
+	for i := 0; i < len(messageIDs); i += 100 {
+		end := min(i+100, len(messageIDs))
+		err := b.Raw("deleteMessages", map[string]any{
+			"chat_id":     chatID,
+			"message_ids": messageIDs[i:end],
+		})
+		if err == nil {
+			deleted += end - i
+		}
+	}
+	return deleted
+}
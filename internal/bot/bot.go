@@ -22,19 +22,25 @@ import (
 
 type HandlerFunc func(*Context) error
 
+func noopHandler(*Context) error { return nil }
+
 type Bot struct {
-	Token       string
-	APIURL      string
-	Client      *fasthttp.Client
-	Store       *store.Store
-	Cfg         *config.Config
-	StartTime   time.Time
-	Handlers    map[string]HandlerFunc
-	Middleware  []func(HandlerFunc) HandlerFunc
-	bufferPool  sync.Pool
-	contextPool sync.Pool
-	limiter     *rate.Limiter
-	Me          *User
+	Token         string
+	APIURL        string
+	Client        *fasthttp.Client
+	Store         *store.Store
+	Cfg           *config.Config
+	StartTime     time.Time
+	Handlers      map[string]HandlerFunc
+	Starts        map[string]HandlerFunc
+	Events        map[string][]HandlerFunc
+	Middleware    []func(HandlerFunc) HandlerFunc
+	bufferPool    sync.Pool
+	contextPool   sync.Pool
+	limiter       *rate.Limiter
+	routes        map[string]fasthttp.RequestHandler
+	conversations []*Conversation
+	Me            *User
 }
 
 func New(cfg *config.Config, store *store.Store) (*Bot, error) {
@@ -46,7 +52,7 @@ func New(cfg *config.Config, store *store.Store) (*Bot, error) {
 		MaxIdleConnDuration: 90 * time.Second,
 	}
 
-	return &Bot{
+	b := &Bot{
 		Token:     cfg.BotToken,
 		APIURL:    cfg.BotAPIURL,
 		Client:    client,
@@ -54,6 +60,8 @@ func New(cfg *config.Config, store *store.Store) (*Bot, error) {
 		Cfg:       cfg,
 		StartTime: time.Now(),
 		Handlers:  make(map[string]HandlerFunc),
+		Starts:    make(map[string]HandlerFunc),
+		Events:    make(map[string][]HandlerFunc),
 		bufferPool: sync.Pool{
 			New: func() any {
 				return bytes.NewBuffer(make([]byte, 0, 512))
@@ -65,7 +73,13 @@ func New(cfg *config.Config, store *store.Store) (*Bot, error) {
 			},
 		},
 		limiter: rate.NewLimiter(rate.Limit(100), 200),
-	}, nil
+		routes:  make(map[string]fasthttp.RequestHandler),
+	}
+
+	b.Use(b.converse)
+	b.Handle(conversationButton, b.onConversationButton)
+	b.Handle(conversationCancel, b.onConversationButton)
+	return b, nil
 }
 
 func (b *Bot) GetMe() (*User, error) {
@@ -162,6 +176,23 @@ func (b *Bot) process(h HandlerFunc, ctx *Context) {
 }
 
 func (b *Bot) Raw(method string, payload any) error {
+	_, err := b.call(method, payload)
+	return err
+}
+
+func (b *Bot) RawMessage(method string, payload any) (*Message, error) {
+	result, err := b.call(method, payload)
+	if err != nil {
+		return nil, err
+	}
+	var msg Message
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (b *Bot) call(method string, payload any) (json.RawMessage, error) {
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseRequest(req)
@@ -176,27 +207,46 @@ func (b *Bot) Raw(method string, payload any) error {
 	defer b.bufferPool.Put(buf)
 
 	if err := json.NewEncoder(buf).Encode(payload); err != nil {
-		return err
+		return nil, err
 	}
 	req.SetBody(buf.Bytes())
 
 	if err := b.Client.Do(req, resp); err != nil {
-		return err
+		return nil, err
 	}
 
 	var res struct {
-		Ok          bool   `json:"ok"`
-		ErrorCode   int    `json:"error_code"`
-		Description string `json:"description"`
+		Ok          bool                `json:"ok"`
+		ErrorCode   int                 `json:"error_code"`
+		Description string              `json:"description"`
+		Parameters  *ResponseParameters `json:"parameters,omitempty"`
+		Result      json.RawMessage     `json:"result"`
 	}
 	if err := json.Unmarshal(resp.Body(), &res); err != nil {
-		return err
+		return nil, err
 	}
 	if !res.Ok {
-		return fmt.Errorf("api error: %d %s", res.ErrorCode, res.Description)
+		if res.Parameters != nil && res.Parameters.MigrateToChatID != 0 {
+			b.migrateFromError(buf.Bytes(), res.Parameters.MigrateToChatID)
+		}
+		return nil, fmt.Errorf("api error: %d %s", res.ErrorCode, res.Description)
 	}
 
-	return nil
+	return append(json.RawMessage(nil), res.Result...), nil
+}
+
+func (b *Bot) migrateFromError(body []byte, newID int64) {
+	var req struct {
+		ChatID int64 `json:"chat_id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.ChatID == 0 {
+		return
+	}
+
+	update := &Update{Message: &Message{Chat: &Chat{ID: req.ChatID}, MigrateToChatID: newID}}
+	b.emit(EventMigrated, update, func(c *Context) {
+		c.Message = update.Message
+	})
 }
 
 func (b *Bot) CheckAdmin(c *Context, chat *Chat, user *User, perms ...string) bool {
@@ -233,13 +283,14 @@ func (b *Bot) IsAdmin(chat *Chat, user *User, perms ...string) bool {
 	keyBuf = strconv.AppendInt(keyBuf, chat.ID, 10)
 	keyBuf = append(keyBuf, ':')
 	keyBuf = strconv.AppendInt(keyBuf, user.ID, 10)
+	key := string(keyBuf)
+
+	field := "any"
 	if len(perms) > 0 {
-		keyBuf = append(keyBuf, ':')
-		keyBuf = append(keyBuf, strings.Join(perms, ",")...)
+		field = strings.Join(perms, ",")
 	}
-	key := string(keyBuf)
 
-	val, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Get().Key(key).Build()).ToString()
+	val, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Hget().Key(key).Field(field).Build()).ToString()
 	if err == nil {
 		return val == "1"
 	}
@@ -330,7 +381,8 @@ func (b *Bot) IsAdmin(chat *Chat, user *User, perms ...string) bool {
 	if isAdmin {
 		v = "1"
 	}
-	b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Set().Key(key).Value(v).Ex(2*time.Minute).Build())
+	b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Hset().Key(key).FieldValue().FieldValue(field, v).Build())
+	b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Expire().Key(key).Seconds(120).Build())
 
 	return isAdmin
 }
@@ -376,6 +428,34 @@ func (b *Bot) ResolveChat(identity string) (*Chat, error) {
 	return &res.Result, nil
 }
 
+func (b *Bot) GetChatMemberCount(chatID int64) (int, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetRequestURI(b.APIURL + "/bot" + b.Token + "/getChatMemberCount")
+	req.SetBody(fmt.Appendf(nil, `{"chat_id": %d}`, chatID))
+
+	if err := b.Client.Do(req, resp); err != nil {
+		return 0, err
+	}
+
+	var res struct {
+		Ok     bool `json:"ok"`
+		Result int  `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Body(), &res); err != nil {
+		return 0, err
+	}
+	if !res.Ok {
+		return 0, fmt.Errorf("failed to get member count")
+	}
+	return res.Result, nil
+}
+
 func (b *Bot) GetTargetChat(c *Context) (*Chat, error) {
 	if c.Chat().Type == "private" {
 		connectedChatID, err := b.Store.GetConnection(c.Sender().ID)
@@ -406,6 +486,13 @@ func (b *Bot) StartLongPolling() {
 	b.Me = me
 	log.Info().Msgf("Bot started as %s (@%s)", b.Me.FirstName, b.Me.Username)
 
+	go b.runDeleteQueue()
+
+	if len(b.routes) > 0 {
+		log.Info().Msgf("HTTP server started on port %d", b.Cfg.WebhookPort)
+		go b.serveHTTP()
+	}
+
 	var offset int64 = 0
 	for {
 		updates, err := b.getUpdates(offset)
@@ -437,25 +524,11 @@ func (b *Bot) StartWebhook() {
 	b.Me = me
 	log.Info().Msgf("Bot started as %s (@%s)", b.Me.FirstName, b.Me.Username)
 
-	log.Info().Msgf("Bot started in Webhook mode on port %d", b.Cfg.WebhookPort)
-
-	requestHandler := func(ctx *fasthttp.RequestCtx) {
-		switch string(ctx.Path()) {
-		case b.Cfg.WebhookPath:
-			b.RequestHandler(ctx)
-		default:
-			ctx.Error("not found", fasthttp.StatusNotFound)
-		}
-	}
+	go b.runDeleteQueue()
 
-	server := &fasthttp.Server{
-		Handler: requestHandler,
-		Logger:  &webhookLogger{},
-	}
+	log.Info().Msgf("Bot started in Webhook mode on port %d", b.Cfg.WebhookPort)
 
-	if err := server.ListenAndServe(fmt.Sprintf(":%d", b.Cfg.WebhookPort)); err != nil {
-		log.Fatal().Err(err).Msg("Error in Serve")
-	}
+	b.serveHTTP()
 }
 
 type webhookLogger struct{}
@@ -480,6 +553,7 @@ func (b *Bot) SetWebhook(url string) error {
 	reqData := map[string]any{
 		"url":                  url,
 		"drop_pending_updates": true,
+		"allowed_updates":      allowedUpdates,
 	}
 
 	if b.Cfg.WebhookSecret != "" {
@@ -541,8 +615,9 @@ func (b *Bot) getUpdates(offset int64) ([]Update, error) {
 	req.SetRequestURI(b.APIURL + "/bot" + b.Token + "/getUpdates")
 
 	reqData := map[string]any{
-		"offset":  offset,
-		"timeout": 30,
+		"offset":          offset,
+		"timeout":         30,
+		"allowed_updates": allowedUpdates,
 	}
 
 	buf := b.bufferPool.Get().(*bytes.Buffer)
@@ -575,25 +650,38 @@ func (b *Bot) processUpdate(update *Update) {
 	ctx := b.contextPool.Get().(*Context)
 	ctx.Reset(b, update)
 
-	if update.Message != nil || update.ChannelPost != nil {
+	if update.EditedMessage != nil || update.EditedChannelPost != nil {
+		if update.EditedMessage != nil {
+			ctx.Message = update.EditedMessage
+		} else {
+			ctx.Message = update.EditedChannelPost
+		}
+		ctx.IsEdited = true
+
+		h, ok := b.Handlers["edited_message"]
+		if !ok {
+			h = noopHandler
+		}
+		go b.process(h, ctx)
+	} else if update.Message != nil || update.ChannelPost != nil {
 		if update.Message != nil {
 			ctx.Message = update.Message
 		} else {
 			ctx.Message = update.ChannelPost
 		}
 
-		if len(ctx.Message.NewChatMembers) > 0 {
-			if h, ok := b.Handlers["new_chat_members"]; ok {
-				go b.process(h, ctx)
-			} else {
-				b.contextPool.Put(ctx)
-			}
-		} else if update.Message.LeftChatMember != nil {
-			if h, ok := b.Handlers["left_chat_member"]; ok {
-				go b.process(h, ctx)
-			} else {
-				b.contextPool.Put(ctx)
+		if ctx.Message.MigrateToChatID != 0 || ctx.Message.MigrateFromChatID != 0 {
+			msg := ctx.Message
+			b.contextPool.Put(ctx)
+			b.emit(EventMigrated, update, func(c *Context) {
+				c.Message = msg
+			})
+		} else if ctx.Message.ServiceType() != "" {
+			h, ok := b.Handlers["service_message"]
+			if !ok {
+				h = noopHandler
 			}
+			go b.process(h, ctx)
 		} else {
 			parts := strings.Fields(ctx.Message.Text)
 			if len(parts) > 0 {
@@ -626,21 +714,15 @@ func (b *Bot) processUpdate(update *Update) {
 
 	} else if update.CallbackQuery != nil {
 		ctx.Callback = update.CallbackQuery
-		data := ctx.Callback.Data
-
-		if h, ok := b.Handlers[data]; ok {
-			go b.process(h, ctx)
-			return
-		}
-
-		if idx := strings.Index(data, "|"); idx != -1 {
-			if h, ok := b.Handlers[data[:idx]]; ok {
-				go b.process(h, ctx)
-				return
-			}
-		}
-
+		go b.dispatchCallback(ctx)
+	} else if update.ChatMember != nil || update.MyChatMember != nil {
+		b.contextPool.Put(ctx)
+		b.processMemberUpdate(update)
+	} else if update.ChatJoinRequest != nil {
 		b.contextPool.Put(ctx)
+		b.emit(EventJoinRequest, update, func(c *Context) {
+			c.JoinRequest = update.ChatJoinRequest
+		})
 	} else {
 		b.contextPool.Put(ctx)
 	}
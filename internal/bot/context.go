@@ -1,11 +1,18 @@
 package bot
 
+import "time"
+
 type Context struct {
-	Bot      *Bot
-	Update   *Update
-	Message  *Message
-	Callback *CallbackQuery
-	Args     []string
+	Bot         *Bot
+	Update      *Update
+	Message     *Message
+	Callback    *CallbackQuery
+	ChatMember  *ChatMemberUpdated
+	JoinRequest *ChatJoinRequest
+	Session     *CallbackSession
+	Args        []string
+	IsEdited    bool
+	AutoDelete  time.Duration
 }
 
 func (c *Context) Reset(b *Bot, u *Update) {
@@ -13,7 +20,12 @@ func (c *Context) Reset(b *Bot, u *Update) {
 	c.Update = u
 	c.Message = nil
 	c.Callback = nil
+	c.ChatMember = nil
+	c.JoinRequest = nil
+	c.Session = nil
 	c.Args = nil
+	c.IsEdited = false
+	c.AutoDelete = 0
 }
 
 func (c *Context) Send(text string, opts ...any) error {
@@ -31,7 +43,7 @@ func (c *Context) Send(text string, opts ...any) error {
 		}
 	}
 
-	return c.Bot.Raw("sendMessage", req)
+	return c.send(req)
 }
 
 func (c *Context) Reply(text string, opts ...any) error {
@@ -55,7 +67,18 @@ func (c *Context) Reply(text string, opts ...any) error {
 			req.ReplyMarkup = v
 		}
 	}
-	return c.Bot.Raw("sendMessage", req)
+	return c.send(req)
+}
+
+func (c *Context) send(req SendMessageReq) error {
+	msg, err := c.Bot.RawMessage("sendMessage", req)
+	if err != nil {
+		return err
+	}
+	if c.AutoDelete > 0 {
+		c.Bot.DeleteAfter(req.ChatID, msg.ID, c.AutoDelete)
+	}
+	return nil
 }
 
 func (c *Context) Delete() error {
@@ -120,6 +143,12 @@ func (c *Context) Chat() *Chat {
 	if c.Callback != nil && c.Callback.Message != nil && c.Callback.Message.Chat != nil {
 		return c.Callback.Message.Chat
 	}
+	if c.ChatMember != nil && c.ChatMember.Chat != nil {
+		return c.ChatMember.Chat
+	}
+	if c.JoinRequest != nil && c.JoinRequest.Chat != nil {
+		return c.JoinRequest.Chat
+	}
 	return &Chat{}
 }
 
@@ -130,6 +159,12 @@ func (c *Context) Sender() *User {
 	if c.Message != nil && c.Message.From != nil {
 		return c.Message.From
 	}
+	if c.ChatMember != nil && c.ChatMember.From != nil {
+		return c.ChatMember.From
+	}
+	if c.JoinRequest != nil && c.JoinRequest.From != nil {
+		return c.JoinRequest.From
+	}
 	return &User{}
 }
 
@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	conversationTimeout = 5 * time.Minute
+	conversationButton  = "conv"
+	conversationCancel  = "conv_cancel"
+)
+
+type Step struct {
+	Key      string
+	Prompt   string
+	Options  []string
+	Optional bool
+	Validate func(c *Context, input string, values map[string]string) error
+}
+
+type Conversation struct {
+	Name    string
+	Title   string
+	Perm    string
+	Steps   []Step
+	Timeout time.Duration
+	Done    func(c *Context, target int64, values map[string]string) error
+}
+
+type conversationState struct {
+	Name   string            `json:"n"`
+	Step   int               `json:"s"`
+	Target int64             `json:"t"`
+	Values map[string]string `json:"v"`
+}
+
+func (b *Bot) RegisterConversation(conv *Conversation) {
+	if conv.Timeout == 0 {
+		conv.Timeout = conversationTimeout
+	}
+	b.conversations = append(b.conversations, conv)
+}
+
+func (b *Bot) Conversations() []*Conversation {
+	return b.conversations
+}
+
+func (b *Bot) conversation(name string) *Conversation {
+	for _, conv := range b.conversations {
+		if conv.Name == name {
+			return conv
+		}
+	}
+	return nil
+}
+
+func conversationKey(c *Context) string {
+	return "conv:" + strconv.FormatInt(c.Chat().ID, 10) + ":" + strconv.FormatInt(c.Sender().ID, 10)
+}
+
+func (b *Bot) loadConversation(c *Context) (*conversationState, *Conversation) {
+	val, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Get().Key(conversationKey(c)).Build()).AsBytes()
+	if err != nil {
+		return nil, nil
+	}
+	var st conversationState
+	if err := json.Unmarshal(val, &st); err != nil {
+		return nil, nil
+	}
+	conv := b.conversation(st.Name)
+	if conv == nil {
+		return nil, nil
+	}
+	return &st, conv
+}
+
+func (b *Bot) saveConversation(c *Context, conv *Conversation, st *conversationState) error {
+	data, _ := json.Marshal(st)
+	return b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Set().Key(conversationKey(c)).Value(string(data)).Ex(conv.Timeout).Build()).Error()
+}
+
+func (b *Bot) endConversation(c *Context) {
+	b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Del().Key(conversationKey(c)).Build())
+}
+
+func (b *Bot) StartConversation(c *Context, name string, target int64) error {
+	conv := b.conversation(name)
+	if conv == nil {
+		return c.Send("Unknown setup: " + name)
+	}
+	st := &conversationState{Name: name, Target: target, Values: map[string]string{}}
+	if err := b.saveConversation(c, conv, st); err != nil {
+		return c.Send("Failed to start setup: " + err.Error())
+	}
+	c.Send(conv.Title + " setup started. Send /cancel at any time to stop. Each step times out after " + conv.Timeout.String() + ".")
+	return b.promptStep(c, conv, st)
+}
+
+func (b *Bot) promptStep(c *Context, conv *Conversation, st *conversationState) error {
+	step := conv.Steps[st.Step]
+	text := "Step " + strconv.Itoa(st.Step+1) + "/" + strconv.Itoa(len(conv.Steps)) + ": " + step.Prompt
+	if step.Optional {
+		text += "\nSend /skip to keep the current value."
+	}
+
+	var rows [][]InlineKeyboardButton
+	var row []InlineKeyboardButton
+	for _, opt := range step.Options {
+		row = append(row, b.CallbackButton(opt, CallbackSession{
+			Endpoint: conversationButton,
+			Args:     []string{opt, strconv.Itoa(st.Step)},
+			ChatID:   c.Chat().ID,
+			UserID:   c.Sender().ID,
+		}))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, []InlineKeyboardButton{b.CallbackButton("Cancel", CallbackSession{
+		Endpoint: conversationCancel,
+		ChatID:   c.Chat().ID,
+		UserID:   c.Sender().ID,
+	})})
+
+	return c.Send(text, &ReplyMarkup{InlineKeyboard: rows})
+}
+
+func (b *Bot) advanceConversation(c *Context, conv *Conversation, st *conversationState, input string) error {
+	if conv.Perm != "" && !b.IsAdmin(&Chat{ID: st.Target}, c.Sender(), conv.Perm) {
+		b.endConversation(c)
+		return c.Send("You no longer have permission to change these settings. Setup cancelled.")
+	}
+
+	step := conv.Steps[st.Step]
+	switch {
+	case input == "/skip" && step.Optional:
+	case step.Validate != nil:
+		if err := step.Validate(c, input, st.Values); err != nil {
+			return c.Send(err.Error() + " Try again or send /cancel.")
+		}
+	case input == "":
+		return c.Send("Please send a message. Try again or send /cancel.")
+	case len(step.Options) > 0:
+		input = strings.ToLower(strings.TrimSpace(input))
+		if !slices.Contains(step.Options, input) {
+			return c.Send("Please choose one of: " + strings.Join(step.Options, ", ") + ". Try again or send /cancel.")
+		}
+		st.Values[step.Key] = input
+	default:
+		st.Values[step.Key] = input
+	}
+
+	st.Step++
+	if st.Step >= len(conv.Steps) {
+		b.endConversation(c)
+		return conv.Done(c, st.Target, st.Values)
+	}
+	if err := b.saveConversation(c, conv, st); err != nil {
+		return c.Send("Failed to save setup progress: " + err.Error())
+	}
+	return b.promptStep(c, conv, st)
+}
+
+func (b *Bot) converse(next HandlerFunc) HandlerFunc {
+	return func(c *Context) error {
+		if c.Message == nil || c.IsEdited || c.Sender().ID == 0 || c.Message.ServiceType() != "" {
+			return next(c)
+		}
+		st, conv := b.loadConversation(c)
+		if st == nil {
+			return next(c)
+		}
+
+		text := strings.TrimSpace(c.Message.Text)
+		cmd, _, _ := strings.Cut(text, " ")
+		cmd, _, _ = strings.Cut(cmd, "@")
+		switch {
+		case cmd == "/cancel":
+			b.endConversation(c)
+			return c.Send(conv.Title + " setup cancelled.")
+		case cmd == "/skip":
+			return b.advanceConversation(c, conv, st, cmd)
+		case strings.HasPrefix(text, "/"):
+			return next(c)
+		}
+		return b.advanceConversation(c, conv, st, c.Message.Text)
+	}
+}
+
+func (b *Bot) onConversationButton(c *Context) error {
+	st, conv := b.loadConversation(c)
+	if st == nil {
+		return c.Respond("This setup has expired.")
+	}
+	if c.Session == nil || c.Data() == conversationCancel {
+		c.Respond()
+		b.endConversation(c)
+		return c.Edit(conv.Title + " setup cancelled.")
+	}
+	if len(c.Session.Args) < 2 || c.Session.Args[1] != strconv.Itoa(st.Step) {
+		return c.Respond("This step has already been answered.")
+	}
+	c.Respond()
+	return b.advanceConversation(c, conv, st, c.Session.Args[0])
+}
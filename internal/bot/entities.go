@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+func (m *Message) Content() (string, []MessageEntity) {
+	if m.Text != "" {
+		return m.Text, m.Entities
+	}
+	return m.Caption, m.CaptionEntities
+}
+
+func (m *Message) ContentAfter(words int) (string, []MessageEntity) {
+	text, entities := m.Content()
+
+	start := 0
+	for i := 0; i < words; i++ {
+		rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+		start = len(text) - len(rest)
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			return "", nil
+		}
+		start += end
+	}
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	start = len(text) - len(rest)
+
+	return rest, SliceEntities(entities, UTF16Len(text[:start]), UTF16Len(text))
+}
+
+func SliceEntities(entities []MessageEntity, from, to int) []MessageEntity {
+	var out []MessageEntity
+	for _, e := range entities {
+		start, end := max(e.Offset, from), min(e.Offset+e.Length, to)
+		if end <= start {
+			continue
+		}
+		e.Offset, e.Length = start-from, end-start
+		out = append(out, e)
+	}
+	return out
+}
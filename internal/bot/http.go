@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/valyala/fasthttp"
+)
+
+func (b *Bot) HandleHTTP(prefix string, h fasthttp.RequestHandler) {
+	b.routes[prefix] = h
+}
+
+func (b *Bot) route(path string) (fasthttp.RequestHandler, bool) {
+	best := ""
+	for prefix := range b.routes {
+		if (path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	h, ok := b.routes[best]
+	return h, ok
+}
+
+func (b *Bot) serveHTTP() {
+	requestHandler := func(ctx *fasthttp.RequestCtx) {
+		path := string(ctx.Path())
+		if b.Cfg.UseWebhook && path == b.Cfg.WebhookPath {
+			b.RequestHandler(ctx)
+			return
+		}
+		if h, ok := b.route(path); ok {
+			h(ctx)
+			return
+		}
+		ctx.Error("not found", fasthttp.StatusNotFound)
+	}
+
+	server := &fasthttp.Server{
+		Handler: requestHandler,
+		Logger:  &webhookLogger{},
+	}
+
+	if err := server.ListenAndServe(fmt.Sprintf(":%d", b.Cfg.WebhookPort)); err != nil {
+		log.Fatal().Err(err).Msg("Error in Serve")
+	}
+}
@@ -0,0 +1,23 @@
+package bot
+
+func (m *Message) Media() (string, string) {
+	switch {
+	case len(m.Photo) > 0:
+		return "photo", m.Photo[len(m.Photo)-1].FileID
+	case m.Video != nil:
+		return "video", m.Video.FileID
+	case m.VideoNote != nil:
+		return "videonote", m.VideoNote.FileID
+	case m.Animation != nil:
+		return "animation", m.Animation.FileID
+	case m.Sticker != nil:
+		return "sticker", m.Sticker.FileID
+	case m.Voice != nil:
+		return "voice", m.Voice.FileID
+	case m.Audio != nil:
+		return "audio", m.Audio.FileID
+	case m.Document != nil:
+		return "document", m.Document.FileID
+	}
+	return "text", ""
+}
@@ -0,0 +1,111 @@
+package bot
+
+const (
+	EventJoin       = "member_join"
+	EventLeave      = "member_leave"
+	EventKicked     = "member_kicked"
+	EventPromoted   = "member_promoted"
+	EventDemoted    = "member_demoted"
+	EventRestricted = "member_restricted"
+	EventBotAdded   = "bot_added"
+	EventBotRemoved = "bot_removed"
+
+	EventJoinRequest = "join_request"
+	EventMigrated    = "chat_migrated"
+)
+
+var allowedUpdates = []string{
+	"message",
+	"edited_message",
+	"channel_post",
+	"edited_channel_post",
+	"callback_query",
+	"my_chat_member",
+	"chat_member",
+	"chat_join_request",
+}
+
+func (b *Bot) On(event string, h HandlerFunc) {
+	b.Events[event] = append(b.Events[event], h)
+}
+
+func (b *Bot) emit(event string, update *Update, setup func(*Context)) {
+	for _, h := range b.Events[event] {
+		ctx := b.contextPool.Get().(*Context)
+		ctx.Reset(b, update)
+		setup(ctx)
+		go b.process(h, ctx)
+	}
+}
+
+func isPresent(m *ChatMember) bool {
+	switch m.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return m.IsMember
+	}
+	return false
+}
+
+func isAdminStatus(status string) bool {
+	return status == "creator" || status == "administrator"
+}
+
+func ClassifyMemberUpdate(u *ChatMemberUpdated, self bool) string {
+	if u.OldChatMember == nil || u.NewChatMember == nil {
+		return ""
+	}
+	was, is := isPresent(u.OldChatMember), isPresent(u.NewChatMember)
+
+	switch {
+	case !was && is:
+		if self {
+			return EventBotAdded
+		}
+		return EventJoin
+	case was && !is:
+		if self {
+			return EventBotRemoved
+		}
+		if u.NewChatMember.Status == "kicked" {
+			return EventKicked
+		}
+		return EventLeave
+	case !is:
+		return ""
+	}
+
+	oldAdmin := isAdminStatus(u.OldChatMember.Status)
+	newAdmin := isAdminStatus(u.NewChatMember.Status)
+	switch {
+	case !oldAdmin && newAdmin:
+		return EventPromoted
+	case oldAdmin && !newAdmin:
+		return EventDemoted
+	case u.NewChatMember.Status == "restricted":
+		return EventRestricted
+	}
+	return ""
+}
+
+func (b *Bot) processMemberUpdate(update *Update) {
+	u, self := update.ChatMember, false
+	if u == nil {
+		u, self = update.MyChatMember, true
+	}
+	if u.Chat == nil || u.NewChatMember == nil || u.NewChatMember.User == nil {
+		return
+	}
+
+	b.InvalidateAdminCache(u.Chat.ID, u.NewChatMember.User.ID)
+
+	event := ClassifyMemberUpdate(u, self)
+	if event == "" {
+		return
+	}
+
+	b.emit(event, update, func(c *Context) {
+		c.ChatMember = u
+	})
+}
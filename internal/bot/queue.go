@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (b *Bot) Schedule(queue, member string, at time.Time) {
+	err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Zadd().Key(queue).ScoreMember().ScoreMember(float64(at.Unix()), member).Build()).Error()
+	if err != nil {
+		log.Error().Err(err).Str("queue", queue).Msg("Failed to schedule job")
+	}
+}
+
+func (b *Bot) Unschedule(queue, member string) bool {
+	removed, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Zrem().Key(queue).Member(member).Build()).AsInt64()
+	return err == nil && removed > 0
+}
+
+func (b *Bot) RunQueue(queue string, interval time.Duration, handle func(member string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := strconv.FormatInt(time.Now().Unix(), 10)
+		members, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Zrangebyscore().Key(queue).Min("-inf").Max(now).Limit(0, 100).Build()).AsStrSlice()
+		if err != nil {
+			continue
+		}
+
+		for _, member := range members {
+			if b.Unschedule(queue, member) {
+				handle(member)
+			}
+		}
+	}
+}
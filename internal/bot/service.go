@@ -0,0 +1,42 @@
+package bot
+
+const (
+	ServiceJoin      = "join"
+	ServiceLeave     = "leave"
+	ServicePin       = "pin"
+	ServicePhoto     = "photo"
+	ServiceTitle     = "title"
+	ServiceVideoChat = "videochat"
+	ServiceTopic     = "topic"
+	ServiceBoost     = "boost"
+)
+
+var ServiceTypes = []string{
+	ServiceJoin, ServiceLeave, ServicePin, ServicePhoto,
+	ServiceTitle, ServiceVideoChat, ServiceTopic, ServiceBoost,
+}
+
+func (m *Message) ServiceType() string {
+	switch {
+	case len(m.NewChatMembers) > 0:
+		return ServiceJoin
+	case m.LeftChatMember != nil:
+		return ServiceLeave
+	case m.PinnedMessage != nil:
+		return ServicePin
+	case len(m.NewChatPhoto) > 0 || m.DeleteChatPhoto:
+		return ServicePhoto
+	case m.NewChatTitle != "":
+		return ServiceTitle
+	case m.VideoChatScheduled != nil || m.VideoChatStarted != nil ||
+		m.VideoChatEnded != nil || m.VideoChatParticipantsInvited != nil:
+		return ServiceVideoChat
+	case m.ForumTopicCreated != nil || m.ForumTopicEdited != nil ||
+		m.ForumTopicClosed != nil || m.ForumTopicReopened != nil ||
+		m.GeneralForumTopicHidden != nil || m.GeneralForumTopicUnhidden != nil:
+		return ServiceTopic
+	case m.BoostAdded != nil:
+		return ServiceBoost
+	}
+	return ""
+}
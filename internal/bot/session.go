@@ -0,0 +1,174 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sessionPrefix   = "~"
+	signedPrefix    = "!"
+	SessionTTL      = 48 * time.Hour
+	maxCallbackData = 64
+)
+
+type CallbackSession struct {
+	Endpoint  string   `json:"e"`
+	Args      []string `json:"a,omitempty"`
+	ChatID    int64    `json:"c"`
+	MessageID int64    `json:"m,omitempty"`
+	UserID    int64    `json:"u,omitempty"`
+	Token     string   `json:"-"`
+}
+
+func (s *CallbackSession) Data() string {
+	return strings.Join(append([]string{s.Endpoint}, s.Args...), "|")
+}
+
+func sessionKey(token string) string {
+	return "cbs:" + token
+}
+
+func (b *Bot) NewCallback(s CallbackSession) (string, error) {
+	token, err := gonanoid.New(16)
+	if err != nil {
+		return "", err
+	}
+	if len(sessionPrefix+token) > maxCallbackData {
+		return "", errors.New("callback data too long")
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	err = b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Set().Key(sessionKey(token)).Value(string(data)).Ex(SessionTTL).Build()).Error()
+	if err != nil {
+		return "", err
+	}
+	return sessionPrefix + token, nil
+}
+
+// CallbackButton never falls back to unbound callback data: if the session
+// can't be stored, the button resolves as expired.
+func (b *Bot) CallbackButton(text string, s CallbackSession) InlineKeyboardButton {
+	data, err := b.NewCallback(s)
+	if err != nil {
+		log.Error().Err(err).Str("endpoint", s.Endpoint).Msg("Failed to create callback session")
+		data = sessionPrefix
+	}
+	return InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+// SignedCallback carries its arguments in the callback data under an HMAC
+// instead of a stored session, so the button never expires. It returns "" if
+// the result doesn't fit in callback data.
+func (b *Bot) SignedCallback(endpoint string, args ...string) string {
+	data := []byte(strings.Join(args, "\x00"))
+	sig := b.signStart(signedPrefix+endpoint, data)
+	cb := signedPrefix + endpoint + "|" + base64.RawURLEncoding.EncodeToString(append(sig, data...))
+	if len(cb) > maxCallbackData {
+		return ""
+	}
+	return cb
+}
+
+func (b *Bot) resolveSigned(cb *CallbackQuery) *CallbackSession {
+	endpoint, encoded, ok := strings.Cut(strings.TrimPrefix(cb.Data, signedPrefix), "|")
+	if !ok || cb.Message == nil || cb.Message.Chat == nil {
+		return nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) <= startSigSize {
+		return nil
+	}
+	sig, data := raw[:startSigSize], raw[startSigSize:]
+	if !hmac.Equal(sig, b.signStart(signedPrefix+endpoint, data)) {
+		return nil
+	}
+	return &CallbackSession{
+		Endpoint:  endpoint,
+		Args:      strings.Split(string(data), "\x00"),
+		ChatID:    cb.Message.Chat.ID,
+		MessageID: cb.Message.ID,
+		Token:     cb.Data,
+	}
+}
+
+func (b *Bot) resolveCallback(cb *CallbackQuery) (*CallbackSession, string) {
+	token := strings.TrimPrefix(cb.Data, sessionPrefix)
+	val, err := b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Get().Key(sessionKey(token)).Build()).AsBytes()
+	if err != nil {
+		return nil, "This button has expired."
+	}
+	var s CallbackSession
+	if err := json.Unmarshal(val, &s); err != nil {
+		return nil, "This button has expired."
+	}
+
+	if cb.Message == nil || cb.Message.Chat == nil || cb.Message.Chat.ID != s.ChatID {
+		return nil, "This button does not belong here."
+	}
+	if s.UserID != 0 && (cb.From == nil || cb.From.ID != s.UserID) {
+		return nil, "This button is not for you."
+	}
+	switch s.MessageID {
+	case 0:
+		s.MessageID = cb.Message.ID
+		data, _ := json.Marshal(s)
+		b.Store.Valkey.Do(context.Background(), b.Store.Valkey.B().Set().Key(sessionKey(token)).Value(string(data)).Keepttl().Build())
+	case cb.Message.ID:
+	default:
+		return nil, "This button does not belong here."
+	}
+	s.Token = cb.Data
+	return &s, ""
+}
+
+func (b *Bot) dispatchCallback(ctx *Context) {
+	if strings.HasPrefix(ctx.Callback.Data, sessionPrefix) {
+		s, reason := b.resolveCallback(ctx.Callback)
+		if s == nil {
+			b.Raw("answerCallbackQuery", map[string]any{
+				"callback_query_id": ctx.Callback.ID,
+				"text":              reason,
+			})
+			b.contextPool.Put(ctx)
+			return
+		}
+		ctx.Session = s
+		ctx.Callback.Data = s.Data()
+	} else if strings.HasPrefix(ctx.Callback.Data, signedPrefix) {
+		s := b.resolveSigned(ctx.Callback)
+		if s == nil {
+			b.Raw("answerCallbackQuery", map[string]any{
+				"callback_query_id": ctx.Callback.ID,
+				"text":              "This button is invalid.",
+			})
+			b.contextPool.Put(ctx)
+			return
+		}
+		ctx.Session = s
+		ctx.Callback.Data = s.Data()
+	}
+
+	data := ctx.Callback.Data
+	if h, ok := b.Handlers[data]; ok {
+		b.process(h, ctx)
+		return
+	}
+	if idx := strings.Index(data, "|"); idx != -1 {
+		if h, ok := b.Handlers[data[:idx]]; ok {
+			b.process(h, ctx)
+			return
+		}
+	}
+	b.contextPool.Put(ctx)
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxStartPayload = 64
+	startSigSize    = 6
+)
+
+func (b *Bot) HandleStart(prefix string, h HandlerFunc) {
+	b.Starts[prefix] = h
+}
+
+func (b *Bot) signStart(prefix string, data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(b.Token))
+	mac.Write([]byte(prefix))
+	mac.Write(data)
+	return mac.Sum(nil)[:startSigSize]
+}
+
+func (b *Bot) StartPayload(prefix string, chatID int64, args ...string) string {
+	data := []byte(strings.Join(append([]string{strconv.FormatInt(chatID, 10)}, args...), "\x00"))
+	payload := prefix + "_" + base64.RawURLEncoding.EncodeToString(append(b.signStart(prefix, data), data...))
+	if len(payload) > maxStartPayload {
+		return ""
+	}
+	return payload
+}
+
+func (b *Bot) StartLink(prefix string, chatID int64, args ...string) string {
+	if b.Me == nil || b.Me.Username == "" {
+		return ""
+	}
+	payload := b.StartPayload(prefix, chatID, args...)
+	if payload == "" {
+		return ""
+	}
+	return "https://t.me/" + b.Me.Username + "?start=" + payload
+}
+
+func (b *Bot) ResolveStart(payload string) (HandlerFunc, []string, bool) {
+	prefix, encoded, ok := strings.Cut(payload, "_")
+	if !ok {
+		return nil, nil, false
+	}
+	h, ok := b.Starts[prefix]
+	if !ok {
+		return nil, nil, false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) <= startSigSize {
+		return nil, nil, false
+	}
+	sig, data := raw[:startSigSize], raw[startSigSize:]
+	if !hmac.Equal(sig, b.signStart(prefix, data)) {
+		return nil, nil, false
+	}
+	return h, strings.Split(string(data), "\x00"), true
+}
@@ -1,35 +1,97 @@
 package bot
 
 type Update struct {
-	UpdateID      int64          `json:"update_id"`
-	Message       *Message       `json:"message,omitempty"`
-	ChannelPost   *Message       `json:"channel_post,omitempty"`
-	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+	UpdateID          int64              `json:"update_id"`
+	Message           *Message           `json:"message,omitempty"`
+	EditedMessage     *Message           `json:"edited_message,omitempty"`
+	ChannelPost       *Message           `json:"channel_post,omitempty"`
+	EditedChannelPost *Message           `json:"edited_channel_post,omitempty"`
+	CallbackQuery     *CallbackQuery     `json:"callback_query,omitempty"`
+	MyChatMember      *ChatMemberUpdated `json:"my_chat_member,omitempty"`
+	ChatMember        *ChatMemberUpdated `json:"chat_member,omitempty"`
+	ChatJoinRequest   *ChatJoinRequest   `json:"chat_join_request,omitempty"`
 }
 
 type Message struct {
-	ID              int64           `json:"message_id"`
-	ThreadID        int64           `json:"message_thread_id,omitempty"`
-	Date            int64           `json:"date"`
-	From            *User           `json:"from,omitempty"`
-	Chat            *Chat           `json:"chat"`
-	ReplyTo         *Message        `json:"reply_to_message,omitempty"`
-	ForwardFromChat *Chat           `json:"forward_from_chat,omitempty"`
-	ForwardOrigin   *MessageOrigin  `json:"forward_origin,omitempty"`
-	Sticker         *Sticker        `json:"sticker,omitempty"`
-	ReplyMarkup     *ReplyMarkup    `json:"reply_markup,omitempty"`
-	Video           *Video          `json:"video,omitempty"`
-	Audio           *Audio          `json:"audio,omitempty"`
-	Document        *Document       `json:"document,omitempty"`
-	Voice           *Voice          `json:"voice,omitempty"`
-	Animation       *Animation      `json:"animation,omitempty"`
-	VideoNote       *VideoNote      `json:"video_note,omitempty"`
-	LeftChatMember  *User           `json:"left_chat_member,omitempty"`
-	Text            string          `json:"text,omitempty"`
-	Caption         string          `json:"caption,omitempty"`
-	Entities        []MessageEntity `json:"entities,omitempty"`
-	NewChatMembers  []User          `json:"new_chat_members,omitempty"`
-	Photo           []PhotoSize     `json:"photo,omitempty"`
+	ID                int64           `json:"message_id"`
+	ThreadID          int64           `json:"message_thread_id,omitempty"`
+	Date              int64           `json:"date"`
+	EditDate          int64           `json:"edit_date,omitempty"`
+	From              *User           `json:"from,omitempty"`
+	Chat              *Chat           `json:"chat"`
+	ReplyTo           *Message        `json:"reply_to_message,omitempty"`
+	ForwardFromChat   *Chat           `json:"forward_from_chat,omitempty"`
+	ForwardOrigin     *MessageOrigin  `json:"forward_origin,omitempty"`
+	Sticker           *Sticker        `json:"sticker,omitempty"`
+	ReplyMarkup       *ReplyMarkup    `json:"reply_markup,omitempty"`
+	Video             *Video          `json:"video,omitempty"`
+	Audio             *Audio          `json:"audio,omitempty"`
+	Document          *Document       `json:"document,omitempty"`
+	Voice             *Voice          `json:"voice,omitempty"`
+	Animation         *Animation      `json:"animation,omitempty"`
+	VideoNote         *VideoNote      `json:"video_note,omitempty"`
+	LeftChatMember    *User           `json:"left_chat_member,omitempty"`
+	Text              string          `json:"text,omitempty"`
+	Caption           string          `json:"caption,omitempty"`
+	Entities          []MessageEntity `json:"entities,omitempty"`
+	CaptionEntities   []MessageEntity `json:"caption_entities,omitempty"`
+	NewChatMembers    []User          `json:"new_chat_members,omitempty"`
+	MigrateToChatID   int64           `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatID int64           `json:"migrate_from_chat_id,omitempty"`
+	Photo             []PhotoSize     `json:"photo,omitempty"`
+
+	PinnedMessage                *Message                      `json:"pinned_message,omitempty"`
+	NewChatTitle                 string                        `json:"new_chat_title,omitempty"`
+	NewChatPhoto                 []PhotoSize                   `json:"new_chat_photo,omitempty"`
+	DeleteChatPhoto              bool                          `json:"delete_chat_photo,omitempty"`
+	VideoChatScheduled           *VideoChatScheduled           `json:"video_chat_scheduled,omitempty"`
+	VideoChatStarted             *VideoChatStarted             `json:"video_chat_started,omitempty"`
+	VideoChatEnded               *VideoChatEnded               `json:"video_chat_ended,omitempty"`
+	VideoChatParticipantsInvited *VideoChatParticipantsInvited `json:"video_chat_participants_invited,omitempty"`
+	ForumTopicCreated            *ForumTopicCreated            `json:"forum_topic_created,omitempty"`
+	ForumTopicEdited             *ForumTopicEdited             `json:"forum_topic_edited,omitempty"`
+	ForumTopicClosed             *ForumTopicClosed             `json:"forum_topic_closed,omitempty"`
+	ForumTopicReopened           *ForumTopicReopened           `json:"forum_topic_reopened,omitempty"`
+	GeneralForumTopicHidden      *GeneralForumTopicHidden      `json:"general_forum_topic_hidden,omitempty"`
+	GeneralForumTopicUnhidden    *GeneralForumTopicUnhidden    `json:"general_forum_topic_unhidden,omitempty"`
+	BoostAdded                   *ChatBoostAdded               `json:"boost_added,omitempty"`
+}
+
+type VideoChatScheduled struct {
+	StartDate int64 `json:"start_date"`
+}
+
+type VideoChatStarted struct{}
+
+type VideoChatEnded struct {
+	Duration int `json:"duration"`
+}
+
+type VideoChatParticipantsInvited struct {
+	Users []User `json:"users,omitempty"`
+}
+
+type ForumTopicCreated struct {
+	Name              string `json:"name"`
+	IconColor         int    `json:"icon_color"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id,omitempty"`
+}
+
+type ForumTopicEdited struct {
+	Name              string `json:"name,omitempty"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id,omitempty"`
+}
+
+type ForumTopicClosed struct{}
+
+type ForumTopicReopened struct{}
+
+type GeneralForumTopicHidden struct{}
+
+type GeneralForumTopicUnhidden struct{}
+
+type ChatBoostAdded struct {
+	BoostCount int `json:"boost_count"`
 }
 
 type MessageOrigin struct {
@@ -128,15 +190,23 @@ type ReplyMarkup struct {
 }
 
 type InlineKeyboardButton struct {
-	Text         string `json:"text"`
-	CallbackData string `json:"callback_data,omitempty"`
-	Url          string `json:"url,omitempty"`
+	Text         string      `json:"text"`
+	CallbackData string      `json:"callback_data,omitempty"`
+	Url          string      `json:"url,omitempty"`
+	WebApp       *WebAppInfo `json:"web_app,omitempty"`
+}
+
+type WebAppInfo struct {
+	Url string `json:"url"`
 }
 
 type ChatMember struct {
 	User                *User  `json:"user"`
 	Status              string `json:"status"`
 	Role                string `json:"custom_title,omitempty"`
+	IsMember            bool   `json:"is_member,omitempty"`
+	UntilDate           int64  `json:"until_date,omitempty"`
+	CanSendMessages     bool   `json:"can_send_messages,omitempty"`
 	CanPromoteMembers   bool   `json:"can_promote_members,omitempty"`
 	CanChangeInfo       bool   `json:"can_change_info,omitempty"`
 	CanDeleteMessages   bool   `json:"can_delete_messages,omitempty"`
@@ -147,6 +217,28 @@ type ChatMember struct {
 	CanManageVideoChats bool   `json:"can_manage_video_chats,omitempty"`
 }
 
+type ChatMemberUpdated struct {
+	Chat           *Chat       `json:"chat"`
+	From           *User       `json:"from"`
+	Date           int64       `json:"date"`
+	OldChatMember  *ChatMember `json:"old_chat_member"`
+	NewChatMember  *ChatMember `json:"new_chat_member"`
+	ViaJoinRequest bool        `json:"via_join_request,omitempty"`
+}
+
+type ChatJoinRequest struct {
+	Chat       *Chat  `json:"chat"`
+	From       *User  `json:"from"`
+	UserChatID int64  `json:"user_chat_id"`
+	Date       int64  `json:"date"`
+	Bio        string `json:"bio,omitempty"`
+}
+
+type ResponseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
+	RetryAfter      int   `json:"retry_after,omitempty"`
+}
+
 type SendMessageReq struct {
 	ChatID           int64        `json:"chat_id"`
 	Text             string       `json:"text"`
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const initDataMaxAge = 24 * time.Hour
+
+var ErrInvalidInitData = errors.New("invalid init data")
+
+func (b *Bot) ValidateInitData(initData string) (*User, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, ErrInvalidInitData
+	}
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, ErrInvalidInitData
+	}
+
+	pairs := make([]string, 0, len(values))
+	for k := range values {
+		if k == "hash" {
+			continue
+		}
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	sort.Strings(pairs)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(b.Token))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+
+	expected, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return nil, ErrInvalidInitData
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil || time.Since(time.Unix(authDate, 0)) > initDataMaxAge {
+		return nil, ErrInvalidInitData
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, ErrInvalidInitData
+	}
+	return &user, nil
+}
@@ -33,6 +33,10 @@ type Config struct {
 	WebhookPort   int
 	WebhookPath   string
 	WebhookSecret string
+
+	WebAppURL    string
+	APIEnabled   bool
+	RelayEnabled bool
 }
 
 func Load() *Config {
@@ -68,6 +72,10 @@ func Load() *Config {
 		WebhookPort:   getEnvAsInt("WEBHOOK_PORT", 8080),
 		WebhookPath:   getEnv("WEBHOOK_PATH", "/webhook"),
 		WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
+
+		WebAppURL:    getEnv("WEBAPP_URL", ""),
+		APIEnabled:   getEnv("API_ENABLED", "false") == "true",
+		RelayEnabled: getEnv("RELAY_ENABLED", "false") == "true",
 	}
 }
 
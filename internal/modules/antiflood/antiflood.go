@@ -34,7 +34,7 @@ func (m *Module) Register() {
 
 func (m *Module) CheckFlood(next bot.HandlerFunc) bot.HandlerFunc {
 	return func(c *bot.Context) error {
-		if c.Chat().Type == "private" {
+		if c.Message == nil || c.Chat().Type == "private" {
 			return next(c)
 		}
 		if m.Bot.IsAdmin(c.Chat(), c.Sender(), "can_restrict_members") {
@@ -105,7 +105,7 @@ func (m *Module) takeAction(c *bot.Context, group *store.Group) {
 	var until time.Time
 	var permissions map[string]bool
 
-	logMsg := "Antiflood triggered for " + c.Sender().FirstName + " (ID: " + strconv.FormatInt(c.Sender().ID, 10) + ")\nAction: " + action
+	ev := logging.Event{Type: "flood", ChatID: c.Chat().ID, Category: "automated", Target: c.Sender(), Text: "Action: " + action}
 
 	switch action {
 	case "ban":
@@ -134,7 +134,7 @@ func (m *Module) takeAction(c *bot.Context, group *store.Group) {
 			"user_id":    c.Sender().ID,
 			"until_date": until.Unix(),
 		})
-		logMsg += "\nDuration: " + duration
+		ev.Duration = duration
 	case "tmute":
 		d, _ := time.ParseDuration(duration)
 		until = time.Now().Add(d)
@@ -145,7 +145,7 @@ func (m *Module) takeAction(c *bot.Context, group *store.Group) {
 			"permissions": permissions,
 			"until_date":  until.Unix(),
 		})
-		logMsg += "\nDuration: " + duration
+		ev.Duration = duration
 	default:
 		permissions = map[string]bool{"can_send_messages": false}
 		err = c.Bot.Raw("restrictChatMember", map[string]any{
@@ -161,13 +161,15 @@ func (m *Module) takeAction(c *bot.Context, group *store.Group) {
 		return
 	}
 
-	m.Logger.Log(c.Chat().ID, "automated", logMsg)
-
-	c.Send("Anti-flood triggered. Action: " + action + " on " + c.Sender().FirstName + ".")
-
 	if group.AntifloodDelete {
+		ev.Evidence = &logging.Evidence{Source: "antiflood", Message: c.Message}
+		m.Logger.Capture(&ev)
 		c.Delete()
 	}
+	m.Logger.Emit(ev)
+
+	c.AutoDelete = group.AutoDeleteAfter("antiflood")
+	c.Send("Anti-flood triggered. Action: " + action + " on " + c.Sender().FirstName + ".")
 }
 
 func (m *Module) handleFlood(c *bot.Context) error {
@@ -220,7 +222,7 @@ func (m *Module) handleSetFlood(c *bot.Context) error {
 	}
 
 	m.Store.SetAntifloodConsecutiveLimit(c.Chat().ID, val)
-	m.Logger.Log(c.Chat().ID, "settings", "Antiflood consecutive limit set to "+arg+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Antiflood consecutive limit set to " + arg})
 	return c.Send("Antiflood consecutive limit set to " + arg + ".")
 }
 
@@ -238,7 +240,7 @@ func (m *Module) handleSetFloodTimer(c *bot.Context) error {
 
 	if strings.ToLower(args[0]) == "off" || strings.ToLower(args[0]) == "no" {
 		m.Store.SetAntifloodTimer(c.Chat().ID, 0, "")
-		m.Logger.Log(c.Chat().ID, "settings", "Timed antiflood disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Timed antiflood disabled"})
 		return c.Send("Timed antiflood disabled.")
 	}
 
@@ -257,7 +259,7 @@ func (m *Module) handleSetFloodTimer(c *bot.Context) error {
 	}
 
 	m.Store.SetAntifloodTimer(c.Chat().ID, count, args[1])
-	m.Logger.Log(c.Chat().ID, "settings", "Timed antiflood set to "+strconv.Itoa(count)+" in "+args[1]+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Timed antiflood set to " + strconv.Itoa(count) + " in " + args[1]})
 	return c.Send("Timed antiflood set: " + strconv.Itoa(count) + " messages in " + args[1] + ".")
 }
 
@@ -275,7 +277,7 @@ func (m *Module) handleFloodMode(c *bot.Context) error {
 
 	action := strings.Join(args, " ")
 	m.Store.SetAntifloodAction(c.Chat().ID, action)
-	m.Logger.Log(c.Chat().ID, "settings", "Antiflood action set to "+action+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Antiflood action set to " + action})
 	return c.Send("Antiflood action set to: " + action)
 }
 
@@ -295,6 +297,6 @@ func (m *Module) handleClearFlood(c *bot.Context) error {
 	enabled := arg == "yes" || arg == "on"
 
 	m.Store.SetAntifloodDelete(c.Chat().ID, enabled)
-	m.Logger.Log(c.Chat().ID, "settings", "Antiflood message deletion set to "+strconv.FormatBool(enabled)+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Antiflood message deletion set to " + strconv.FormatBool(enabled)})
 	return c.Send("Clear flood set to: " + strconv.FormatBool(enabled))
 }
@@ -26,50 +26,43 @@ func (m *Module) Register() {
 	m.Bot.Handle("/raidtime", m.handleRaidTime)
 	m.Bot.Handle("/raidactiontime", m.handleRaidActionTime)
 	m.Bot.Handle("/autoantiraid", m.handleAutoAntiraid)
-	m.Bot.Handle("new_chat_members", m.handleUserJoined)
+	m.Bot.On(bot.EventJoin, m.handleUserJoined)
 }
 
 func (m *Module) handleUserJoined(c *bot.Context) error {
-	targetChat, err := m.Bot.GetTargetChat(c)
-	if err != nil {
-		return c.Send("Error resolving chat.")
-	}
+	chat := c.Chat()
+	u := c.ChatMember.NewChatMember.User
 
-	if !m.Bot.CheckAdmin(c, targetChat, c.Sender(), "can_restrict_members") {
-		return nil
-	}
-	if !m.Bot.CheckBotAdmin(c, targetChat, "can_restrict_members") {
+	if m.Bot.Me == nil || !m.Bot.IsAdmin(chat, m.Bot.Me, "can_restrict_members") {
 		return nil
 	}
 
-	group, err := m.Store.GetGroup(targetChat.ID)
+	group, err := m.Store.GetGroup(chat.ID)
 	if err != nil || group == nil {
 		return nil
 	}
 
 	if group.AntiraidUntil != nil && group.AntiraidUntil.After(time.Now()) {
-		for _, u := range c.Update.Message.NewChatMembers {
-			m.banUserRaw(targetChat.ID, u.ID, group.RaidActionTime)
-			m.Logger.Log(targetChat.ID, "automated", "Antiraid banned user: "+u.FirstName+" (ID: "+strconv.FormatInt(u.ID, 10)+")")
-		}
+		m.banUserRaw(chat.ID, u.ID, group.RaidActionTime)
+		m.Logger.Emit(logging.Event{Type: "ban", ChatID: chat.ID, Category: "automated", Target: u, Duration: group.RaidActionTime, Text: "Antiraid is active."})
 		return nil
 	}
 
 	if group.AutoAntiraidThreshold > 0 {
-		key := "antiraid:joins:" + strconv.FormatInt(targetChat.ID, 10) + ":" + strconv.FormatInt(time.Now().Unix()/60, 10)
+		key := "antiraid:joins:" + strconv.FormatInt(chat.ID, 10) + ":" + strconv.FormatInt(time.Now().Unix()/60, 10)
 		val, _ := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Incr().Key(key).Build()).AsInt64()
 		m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Expire().Key(key).Seconds(65).Build())
 
 		if val >= int64(group.AutoAntiraidThreshold) {
-			until := time.Now().Add(6 * time.Hour)
-			m.Store.SetAntiraidUntil(targetChat.ID, &until)
-			c.Send("🚨 **ANTI-RAID AUTOMATICALLY ENABLED** 🚨\nMore than "+strconv.Itoa(group.AutoAntiraidThreshold)+" joins in the last minute.\nAnti-raid enabled for 6 hours.", "Markdown")
-			m.Logger.Log(targetChat.ID, "automated", "Auto-Antiraid triggered. Threshold: "+strconv.Itoa(group.AutoAntiraidThreshold)+". Enabled for 6h.")
-
-			for _, u := range c.Update.Message.NewChatMembers {
-				m.banUserRaw(targetChat.ID, u.ID, group.RaidActionTime)
-				m.Logger.Log(targetChat.ID, "automated", "Antiraid banned user: "+u.FirstName+" (ID: "+strconv.FormatInt(u.ID, 10)+")")
+			if val == int64(group.AutoAntiraidThreshold) {
+				until := time.Now().Add(6 * time.Hour)
+				m.Store.SetAntiraidUntil(chat.ID, &until)
+				c.Send("🚨 **ANTI-RAID AUTOMATICALLY ENABLED** 🚨\nMore than "+strconv.Itoa(group.AutoAntiraidThreshold)+" joins in the last minute.\nAnti-raid enabled for 6 hours.", "Markdown")
+				m.Logger.Emit(logging.Event{Type: "antiraid_triggered", ChatID: chat.ID, Category: "automated", Reason: "Threshold: " + strconv.Itoa(group.AutoAntiraidThreshold) + " joins/min", Text: "Antiraid enabled for 6h."})
 			}
+
+			m.banUserRaw(chat.ID, u.ID, group.RaidActionTime)
+			m.Logger.Emit(logging.Event{Type: "ban", ChatID: chat.ID, Category: "automated", Target: u, Duration: group.RaidActionTime, Text: "Antiraid is active."})
 		}
 	}
 
@@ -103,7 +96,7 @@ func (m *Module) handleAntiraid(c *bot.Context) error {
 	arg := strings.ToLower(args[0])
 	if arg == "off" || arg == "no" {
 		m.Store.SetAntiraidUntil(c.Chat().ID, nil)
-		m.Logger.Log(c.Chat().ID, "settings", "Antiraid disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Antiraid disabled"})
 		return c.Send("Anti-raid mode disabled.")
 	}
 
@@ -114,7 +107,7 @@ func (m *Module) handleAntiraid(c *bot.Context) error {
 
 	until := time.Now().Add(duration)
 	m.Store.SetAntiraidUntil(c.Chat().ID, &until)
-	m.Logger.Log(c.Chat().ID, "settings", "Antiraid enabled until "+until.Format(time.RFC822)+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Antiraid enabled until " + until.Format(time.RFC822)})
 	return c.Send("Anti-raid enabled until " + until.Format(time.RFC822) + ".")
 }
 
@@ -144,7 +137,7 @@ func (m *Module) handleRaidActionTime(c *bot.Context) error {
 	}
 
 	m.Store.SetRaidActionTime(c.Chat().ID, duration)
-	m.Logger.Log(c.Chat().ID, "settings", "Raid action time set to "+duration+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Raid action time set to " + duration})
 	return c.Send("Raid action time set to " + duration + ".")
 }
 
@@ -161,7 +154,7 @@ func (m *Module) handleAutoAntiraid(c *bot.Context) error {
 	arg := strings.ToLower(args[0])
 	if arg == "off" || arg == "no" {
 		m.Store.SetAutoAntiraidThreshold(c.Chat().ID, 0)
-		m.Logger.Log(c.Chat().ID, "settings", "Auto-Antiraid disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Auto-Antiraid disabled"})
 		return c.Send("Automatic anti-raid disabled.")
 	}
 
@@ -171,6 +164,6 @@ func (m *Module) handleAutoAntiraid(c *bot.Context) error {
 	}
 
 	m.Store.SetAutoAntiraidThreshold(c.Chat().ID, threshold)
-	m.Logger.Log(c.Chat().ID, "settings", "Auto-Antiraid set to "+strconv.Itoa(threshold)+" joins/min by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Auto-Antiraid set to " + strconv.Itoa(threshold) + " joins/min"})
 	return c.Send("Automatic anti-raid set to trigger at " + strconv.Itoa(threshold) + " joins/minute.")
 }
@@ -0,0 +1,311 @@
+package api
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/filters"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/modules/moderation"
+	"lappbot/internal/store"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+const basePath = "/api/v1"
+
+type Module struct {
+	Bot        *bot.Bot
+	Store      *store.Store
+	Logger     *logging.Module
+	Filters    *filters.FiltersModule
+	Moderation *moderation.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module, f *filters.FiltersModule, mod *moderation.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l, Filters: f, Moderation: mod}
+}
+
+type request struct {
+	ctx    *fasthttp.RequestCtx
+	key    *store.APIKey
+	chatID int64
+	params map[string]string
+}
+
+type handler func(r *request) (any, error)
+
+type route struct {
+	method  string
+	pattern []string
+	owner   bool
+	handler handler
+}
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func badRequest(message string) error {
+	return &apiError{status: fasthttp.StatusBadRequest, message: message}
+}
+
+func notFound(message string) error {
+	return &apiError{status: fasthttp.StatusNotFound, message: message}
+}
+
+func (m *Module) Register() {
+	if !m.Bot.Cfg.APIEnabled {
+		return
+	}
+	m.Bot.Handle("/newapikey", m.handleNewKey)
+	m.Bot.Handle("/apikeys", m.handleListKeys)
+	m.Bot.Handle("/revokeapikey", m.handleRevokeKey)
+	m.Bot.HandleHTTP(basePath, m.serve)
+}
+
+func (m *Module) keyScope(c *bot.Context) (int64, bool) {
+	if c.Chat().Type != "private" {
+		c.Send("API keys can only be managed in PM. Connect to the group with /connect first.")
+		return 0, false
+	}
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		c.Send("Error resolving chat.")
+		return 0, false
+	}
+	if target.Type == "private" {
+		if c.Sender().ID != m.Bot.Cfg.BotOwnerID {
+			c.Send("Connect to a group with /connect first.")
+			return 0, false
+		}
+		return 0, true
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info", "can_restrict_members") {
+		return 0, false
+	}
+	return target.ID, true
+}
+
+func scopeName(groupID int64) string {
+	if groupID == 0 {
+		return "all groups (owner)"
+	}
+	return "chat " + strconv.FormatInt(groupID, 10)
+}
+
+func (m *Module) handleNewKey(c *bot.Context) error {
+	groupID, ok := m.keyScope(c)
+	if !ok {
+		return nil
+	}
+
+	name := strings.TrimSpace(strings.Join(c.Args, " "))
+	if name == "" {
+		name = "default"
+	}
+	if len(name) > 64 {
+		return c.Send("Key name is too long.")
+	}
+
+	key, k, err := m.Store.CreateAPIKey(name, groupID, c.Sender().ID)
+	if err != nil {
+		return c.Send("Failed to create API key: " + err.Error())
+	}
+	if groupID != 0 {
+		m.Logger.Emit(logging.Event{Type: "api_key_created", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "API key \"" + name + "\""})
+	}
+	return c.Send("API key created for "+scopeName(groupID)+".\nID: <code>"+k.ID+"</code>\nKey: <code>"+key+"</code>\n\nThis key will not be shown again. Send it as <code>Authorization: Bearer &lt;key&gt;</code>.", "HTML")
+}
+
+func (m *Module) handleListKeys(c *bot.Context) error {
+	groupID, ok := m.keyScope(c)
+	if !ok {
+		return nil
+	}
+
+	keys, err := m.Store.GetAPIKeys(groupID)
+	if err != nil {
+		return c.Send("Failed to fetch API keys: " + err.Error())
+	}
+	if len(keys) == 0 {
+		return c.Send("No API keys for " + scopeName(groupID) + ".")
+	}
+
+	msg := "API keys for " + scopeName(groupID) + ":\n"
+	for _, k := range keys {
+		used := "never used"
+		if k.LastUsedAt != nil {
+			used = "last used " + k.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		msg += "• " + k.ID + " - " + k.Name + " (" + used + ")\n"
+	}
+	return c.Send(msg)
+}
+
+func (m *Module) handleRevokeKey(c *bot.Context) error {
+	groupID, ok := m.keyScope(c)
+	if !ok {
+		return nil
+	}
+	if len(c.Args) == 0 {
+		return c.Send("Usage: /revokeapikey <id>")
+	}
+
+	deleted, err := m.Store.DeleteAPIKey(groupID, c.Args[0])
+	if err != nil {
+		return c.Send("Failed to revoke API key: " + err.Error())
+	}
+	if !deleted {
+		return c.Send("No API key with that ID.")
+	}
+	if groupID != 0 {
+		m.Logger.Emit(logging.Event{Type: "api_key_revoked", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "API key " + c.Args[0]})
+	}
+	return c.Send("API key revoked.")
+}
+
+func match(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[p[1:len(p)-1]] = segments[i]
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (m *Module) serve(ctx *fasthttp.RequestCtx) {
+	path := strings.Trim(strings.TrimPrefix(string(ctx.Path()), basePath), "/")
+	if path == "openapi.json" {
+		ctx.SetContentType("application/json")
+		ctx.SetBody(openAPISpec)
+		return
+	}
+
+	auth := string(ctx.Request.Header.Peek("Authorization"))
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || token == "" {
+		writeError(ctx, fasthttp.StatusUnauthorized, "missing API key")
+		return
+	}
+	key, err := m.Store.GetAPIKey(token)
+	if err != nil {
+		writeError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+	if key == nil {
+		writeError(ctx, fasthttp.StatusUnauthorized, "invalid API key")
+		return
+	}
+	if key.GroupID != 0 && !m.Bot.IsAdmin(&bot.Chat{ID: key.GroupID}, &bot.User{ID: key.CreatedBy}, "can_change_info", "can_restrict_members") {
+		writeError(ctx, fasthttp.StatusForbidden, "the creator of this key is no longer an admin")
+		return
+	}
+
+	segments := strings.Split(path, "/")
+	method := string(ctx.Method())
+	pathMatched := false
+	for _, rt := range m.routes() {
+		params, ok := match(rt.pattern, segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.method != method {
+			continue
+		}
+
+		if rt.owner && key.GroupID != 0 {
+			writeError(ctx, fasthttp.StatusForbidden, "this endpoint requires an owner key")
+			return
+		}
+		r := &request{ctx: ctx, key: key, params: params}
+		if chat, ok := params["chat"]; ok {
+			r.chatID, err = strconv.ParseInt(chat, 10, 64)
+			if err != nil {
+				writeError(ctx, fasthttp.StatusBadRequest, "invalid chat id")
+				return
+			}
+			if key.GroupID != 0 && key.GroupID != r.chatID {
+				writeError(ctx, fasthttp.StatusForbidden, "this key is not scoped to that chat")
+				return
+			}
+		}
+
+		result, err := rt.handler(r)
+		if err != nil {
+			status := fasthttp.StatusInternalServerError
+			if e, ok := err.(*apiError); ok {
+				status = e.status
+			}
+			writeError(ctx, status, err.Error())
+			return
+		}
+		writeJSON(ctx, fasthttp.StatusOK, result)
+		return
+	}
+
+	if pathMatched {
+		writeError(ctx, fasthttp.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeError(ctx, fasthttp.StatusNotFound, "not found")
+}
+
+func (r *request) decode(v any) error {
+	if err := json.Unmarshal(r.ctx.PostBody(), v); err != nil {
+		return badRequest("invalid body: " + err.Error())
+	}
+	return nil
+}
+
+func (r *request) int64Param(name string) (int64, error) {
+	v, err := strconv.ParseInt(r.params[name], 10, 64)
+	if err != nil {
+		return 0, badRequest("invalid " + name)
+	}
+	return v, nil
+}
+
+func (r *request) actor() string {
+	return "API key \"" + r.key.Name + "\""
+}
+
+func (r *request) actorUser() *bot.User {
+	return &bot.User{ID: r.key.CreatedBy, FirstName: r.actor()}
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error("internal error", fasthttp.StatusInternalServerError)
+		return
+	}
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(data)
+}
+
+func writeError(ctx *fasthttp.RequestCtx, status int, message string) {
+	writeJSON(ctx, status, map[string]string{"error": message})
+}
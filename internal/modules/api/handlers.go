@@ -0,0 +1,354 @@
+package api
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+var blacklistTypes = []string{"regex", "sticker_set", "emoji"}
+var blacklistActions = []string{"delete", "soft_warn", "hard_warn", "kick", "mute", "ban"}
+
+var success = map[string]bool{"ok": true}
+
+func (m *Module) routes() []route {
+	return []route{
+		{method: "GET", pattern: []string{"groups"}, handler: m.listGroups},
+		{method: "GET", pattern: []string{"groups", "{chat}"}, handler: m.getGroup},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "notes"}, handler: m.listNotes},
+		{method: "POST", pattern: []string{"groups", "{chat}", "notes"}, handler: m.saveNote},
+		{method: "GET", pattern: []string{"groups", "{chat}", "notes", "{name}"}, handler: m.getNote},
+		{method: "DELETE", pattern: []string{"groups", "{chat}", "notes", "{name}"}, handler: m.deleteNote},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "filters"}, handler: m.listFilters},
+		{method: "POST", pattern: []string{"groups", "{chat}", "filters"}, handler: m.addFilters},
+		{method: "DELETE", pattern: []string{"groups", "{chat}", "filters", "{trigger}"}, handler: m.deleteFilter},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "blacklist"}, handler: m.listBlacklist},
+		{method: "POST", pattern: []string{"groups", "{chat}", "blacklist"}, handler: m.addBlacklist},
+		{method: "DELETE", pattern: []string{"groups", "{chat}", "blacklist", "{type}", "{value}"}, handler: m.deleteBlacklist},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "warns"}, handler: m.listWarns},
+		{method: "DELETE", pattern: []string{"groups", "{chat}", "warns", "{user}"}, handler: m.resetWarns},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "bans"}, handler: m.listBans},
+
+		{method: "GET", pattern: []string{"groups", "{chat}", "approved"}, handler: m.listApproved},
+		{method: "POST", pattern: []string{"groups", "{chat}", "approved"}, handler: m.approve},
+		{method: "DELETE", pattern: []string{"groups", "{chat}", "approved", "{user}"}, handler: m.unapprove},
+
+		{method: "POST", pattern: []string{"groups", "{chat}", "actions", "{action}"}, handler: m.action},
+		{method: "POST", pattern: []string{"realm", "ban"}, owner: true, handler: m.realmBan},
+	}
+}
+
+func (m *Module) listGroups(r *request) (any, error) {
+	if r.key.GroupID == 0 {
+		return m.Store.GetAllGroups()
+	}
+	group, err := m.Store.GetGroup(r.key.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return []store.Group{}, nil
+	}
+	return []store.Group{*group}, nil
+}
+
+func (m *Module) getGroup(r *request) (any, error) {
+	group, err := m.Store.GetGroup(r.chatID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, notFound("group not found")
+	}
+	return group, nil
+}
+
+func (m *Module) listNotes(r *request) (any, error) {
+	return m.Store.GetNotes(r.chatID)
+}
+
+func (m *Module) getNote(r *request) (any, error) {
+	note, err := m.Store.GetNote(r.chatID, strings.ToLower(r.params["name"]))
+	if err != nil {
+		return nil, err
+	}
+	if note == nil {
+		return nil, notFound("note not found")
+	}
+	return note, nil
+}
+
+func (m *Module) saveNote(r *request) (any, error) {
+	var body struct {
+		Name    string `json:"name"`
+		Content string `json:"content"`
+	}
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(strings.TrimSpace(body.Name))
+	if name == "" || strings.ContainsAny(name, " \n") || body.Content == "" {
+		return nil, badRequest("name and content are required")
+	}
+
+	if err := m.Store.SaveNote(r.chatID, name, body.Content, nil, "text", "", r.key.CreatedBy); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "note_saved", ChatID: r.chatID, Category: "other", Actor: r.actorUser(), Text: name})
+	return success, nil
+}
+
+func (m *Module) deleteNote(r *request) (any, error) {
+	name := strings.ToLower(r.params["name"])
+	if err := m.Store.DeleteNote(r.chatID, name); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "note_deleted", ChatID: r.chatID, Category: "other", Actor: r.actorUser(), Text: name})
+	return success, nil
+}
+
+func (m *Module) listFilters(r *request) (any, error) {
+	return m.Store.GetFilters(r.chatID)
+}
+
+type filterInput struct {
+	Trigger  string `json:"trigger"`
+	Response string `json:"response"`
+}
+
+func (m *Module) addFilters(r *request) (any, error) {
+	var inputs []filterInput
+	body := r.ctx.PostBody()
+	if len(body) > 0 && body[0] == '[' {
+		if err := r.decode(&inputs); err != nil {
+			return nil, err
+		}
+	} else {
+		var in filterInput
+		if err := r.decode(&in); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, in)
+	}
+
+	for i, in := range inputs {
+		if strings.TrimSpace(in.Trigger) == "" || in.Response == "" {
+			return nil, badRequest("filter " + strconv.Itoa(i) + ": trigger and response are required")
+		}
+	}
+
+	added := 0
+	for _, in := range inputs {
+		trigger := strings.ToLower(strings.TrimSpace(in.Trigger))
+		if err := m.Store.AddFilter(r.chatID, trigger, in.Response, nil, "text"); err != nil {
+			m.Filters.Invalidate(r.chatID)
+			return nil, err
+		}
+		added++
+	}
+	m.Filters.Invalidate(r.chatID)
+	m.Logger.Emit(logging.Event{Type: "filter_added", ChatID: r.chatID, Category: "other", Actor: r.actorUser(), Text: strconv.Itoa(added) + " filter(s)"})
+	return map[string]int{"added": added}, nil
+}
+
+func (m *Module) deleteFilter(r *request) (any, error) {
+	trigger := strings.ToLower(r.params["trigger"])
+	if err := m.Store.DeleteFilter(r.chatID, trigger); err != nil {
+		return nil, err
+	}
+	m.Filters.Invalidate(r.chatID)
+	m.Logger.Emit(logging.Event{Type: "filter_deleted", ChatID: r.chatID, Category: "other", Actor: r.actorUser(), Text: "Trigger: " + trigger})
+	return success, nil
+}
+
+func (m *Module) listBlacklist(r *request) (any, error) {
+	return m.Store.GetBlacklist(r.chatID)
+}
+
+func (m *Module) addBlacklist(r *request) (any, error) {
+	var body struct {
+		Type     string `json:"type"`
+		Value    string `json:"value"`
+		Action   string `json:"action"`
+		Duration string `json:"duration"`
+	}
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+	kind := strings.ToLower(body.Type)
+	action := strings.ToLower(body.Action)
+	if action == "" {
+		action = "delete"
+	}
+	if !slices.Contains(blacklistTypes, kind) {
+		return nil, badRequest("type must be one of: " + strings.Join(blacklistTypes, ", "))
+	}
+	if !slices.Contains(blacklistActions, action) {
+		return nil, badRequest("action must be one of: " + strings.Join(blacklistActions, ", "))
+	}
+	if body.Value == "" {
+		return nil, badRequest("value is required")
+	}
+
+	if err := m.Store.AddBlacklistItem(r.chatID, kind, body.Value, action, body.Duration); err != nil {
+		return nil, err
+	}
+	m.Moderation.InvalidateBlacklist(r.chatID)
+	m.Logger.Emit(logging.Event{Type: "blacklist_added", ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Text: kind + ": " + body.Value + " (Action: " + action + ")"})
+	return success, nil
+}
+
+func (m *Module) deleteBlacklist(r *request) (any, error) {
+	kind := strings.ToLower(r.params["type"])
+	value := r.params["value"]
+	if err := m.Store.RemoveBlacklistItem(r.chatID, kind, value); err != nil {
+		return nil, err
+	}
+	m.Moderation.InvalidateBlacklist(r.chatID)
+	m.Logger.Emit(logging.Event{Type: "blacklist_removed", ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Text: kind + ": " + value})
+	return success, nil
+}
+
+func (m *Module) listWarns(r *request) (any, error) {
+	return m.Store.GetWarnStats(r.chatID)
+}
+
+func (m *Module) resetWarns(r *request) (any, error) {
+	userID, err := r.int64Param("user")
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Store.ResetWarns(userID, r.chatID); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "warns_reset", ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Target: &bot.User{ID: userID}})
+	return success, nil
+}
+
+func (m *Module) listBans(r *request) (any, error) {
+	return m.Store.GetBans(r.chatID)
+}
+
+func (m *Module) listApproved(r *request) (any, error) {
+	return m.Store.GetApprovedUsers(r.chatID)
+}
+
+type userInput struct {
+	UserID   int64  `json:"user_id"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+}
+
+func (r *request) user() (*userInput, error) {
+	var in userInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if in.UserID == 0 {
+		return nil, badRequest("user_id is required")
+	}
+	return &in, nil
+}
+
+func (m *Module) approve(r *request) (any, error) {
+	in, err := r.user()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Moderation.Approve(r.chatID, in.UserID, r.key.CreatedBy); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "approve", ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Target: &bot.User{ID: in.UserID}})
+	return success, nil
+}
+
+func (m *Module) unapprove(r *request) (any, error) {
+	userID, err := r.int64Param("user")
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Moderation.Unapprove(r.chatID, userID); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "unapprove", ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Target: &bot.User{ID: userID}})
+	return success, nil
+}
+
+func (m *Module) action(r *request) (any, error) {
+	in, err := r.user()
+	if err != nil {
+		return nil, err
+	}
+
+	var until time.Time
+	if in.Duration != "" {
+		d, err := time.ParseDuration(in.Duration)
+		if err != nil || d <= 0 {
+			return nil, badRequest("invalid duration")
+		}
+		until = time.Now().Add(d)
+	}
+
+	action := r.params["action"]
+	chat := &bot.Chat{ID: r.chatID}
+	user := &bot.User{ID: in.UserID}
+	if (action == "ban" || action == "kick" || action == "mute") && m.Bot.IsAdmin(chat, user) {
+		return nil, &apiError{status: fasthttp.StatusConflict, message: "cannot " + action + " an admin"}
+	}
+
+	reason := in.Reason
+	switch action {
+	case "ban":
+		if reason == "" {
+			reason = "Manual Ban"
+		}
+		err = m.Moderation.Ban(r.chatID, in.UserID, until, reason, r.key.CreatedBy)
+	case "unban":
+		err = m.Moderation.Unban(r.chatID, in.UserID)
+	case "kick":
+		err = m.Moderation.Kick(r.chatID, in.UserID)
+	case "mute":
+		if reason == "" {
+			reason = "Manual Mute"
+		}
+		err = m.Moderation.Mute(r.chatID, in.UserID, until, reason, r.key.CreatedBy)
+	case "unmute":
+		err = m.Moderation.Unmute(r.chatID, in.UserID)
+	default:
+		return nil, notFound("unknown action: " + action)
+	}
+	if err != nil {
+		return nil, &apiError{status: fasthttp.StatusBadGateway, message: err.Error()}
+	}
+	m.Logger.Emit(logging.Event{Type: action, ChatID: r.chatID, Category: "admin", Actor: r.actorUser(), Target: user, Reason: reason, Duration: in.Duration})
+	return success, nil
+}
+
+func (m *Module) realmBan(r *request) (any, error) {
+	in, err := r.user()
+	if err != nil {
+		return nil, err
+	}
+	reason := in.Reason
+	if reason == "" {
+		reason = "Realm Ban"
+	}
+
+	banned, failed, err := m.Moderation.RealmBan(&bot.User{ID: in.UserID, FirstName: strconv.FormatInt(in.UserID, 10)}, reason, r.actorUser())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int{"banned": banned, "failed": failed}, nil
+}
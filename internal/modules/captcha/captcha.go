@@ -10,8 +10,8 @@ import (
 
 	"lappbot/internal/bot"
 	"lappbot/internal/modules/logging"
-	"lappbot/internal/modules/utility"
 	"lappbot/internal/store"
+	"lappbot/internal/template"
 
 	"github.com/steambap/captcha"
 )
@@ -31,7 +31,7 @@ const CaptchaDuration = 5 * time.Minute
 func (m *Module) Register() {
 	m.Bot.Use(m.CheckCaptcha)
 	m.Bot.Handle("/captcha", m.handleCaptchaCommand)
-	m.Bot.Handle("new_chat_members", m.OnUserJoined)
+	m.Bot.On(bot.EventJoin, m.OnUserJoined)
 }
 
 func (m *Module) CheckCaptcha(next bot.HandlerFunc) bot.HandlerFunc {
@@ -77,7 +77,11 @@ func (m *Module) CheckCaptcha(next bot.HandlerFunc) bot.HandlerFunc {
 			}
 
 			c.Delete()
+			m.Logger.Emit(logging.Event{Type: "captcha_passed", ChatID: c.Chat().ID, Category: "automated", Target: c.Sender()})
 
+			if group, err := m.Store.GetGroup(c.Chat().ID); err == nil && group != nil {
+				c.AutoDelete = group.AutoDeleteAfter("captcha")
+			}
 			return c.Send("Verification successful! You can now chat.")
 		} else {
 			c.Delete()
@@ -87,69 +91,66 @@ func (m *Module) CheckCaptcha(next bot.HandlerFunc) bot.HandlerFunc {
 }
 
 func (m *Module) OnUserJoined(c *bot.Context) error {
-	if len(c.Update.Message.NewChatMembers) == 0 {
+	u := c.ChatMember.NewChatMember.User
+	if u.IsBot {
 		return nil
 	}
 
-	for _, u := range c.Update.Message.NewChatMembers {
-		if u.IsBot {
-			continue
-		}
-
-		group, err := m.Store.GetGroup(c.Chat().ID)
-		if err != nil {
-			continue
-		}
+	group, err := m.Store.GetGroup(c.Chat().ID)
+	if err != nil {
+		return err
+	}
 
-		if group == nil || !group.CaptchaEnabled {
-			continue
-		}
+	if group == nil || !group.CaptchaEnabled {
+		return nil
+	}
 
-		permissions := map[string]bool{
-			"can_send_messages":         true,
-			"can_send_media_messages":   false,
-			"can_send_polls":            false,
-			"can_send_other_messages":   false,
-			"can_add_web_page_previews": false,
-		}
-		m.Bot.Raw("restrictChatMember", map[string]any{
-			"chat_id":     c.Chat().ID,
-			"user_id":     u.ID,
-			"permissions": permissions,
-		})
+	permissions := map[string]bool{
+		"can_send_messages":         true,
+		"can_send_media_messages":   false,
+		"can_send_polls":            false,
+		"can_send_other_messages":   false,
+		"can_add_web_page_previews": false,
+	}
+	m.Bot.Raw("restrictChatMember", map[string]any{
+		"chat_id":     c.Chat().ID,
+		"user_id":     u.ID,
+		"permissions": permissions,
+	})
 
-		img, err := captcha.New(150, 50)
-		if err != nil {
-			continue
-		}
+	img, err := captcha.New(150, 50)
+	if err != nil {
+		return nil
+	}
 
-		key := "captcha:" + strconv.FormatInt(c.Chat().ID, 10) + ":" + strconv.FormatInt(u.ID, 10)
-		err = m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Set().Key(key).Value(img.Text).Ex(CaptchaDuration).Build()).Error()
-		if err != nil {
-			continue
-		}
+	key := "captcha:" + strconv.FormatInt(c.Chat().ID, 10) + ":" + strconv.FormatInt(u.ID, 10)
+	err = m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Set().Key(key).Value(img.Text).Ex(CaptchaDuration).Build()).Error()
+	if err != nil {
+		return nil
+	}
 
-		buf := new(bytes.Buffer)
-		if err := img.WriteImage(buf); err != nil {
-			continue
-		}
+	buf := new(bytes.Buffer)
+	if err := img.WriteImage(buf); err != nil {
+		return nil
+	}
 
-		code := img.Text
+	code := img.Text
 
-		caption := "Please type the code below to verify you are human."
-		if group.GreetingEnabled && group.GreetingMessage != "" {
-			userPtr := &u
-			caption = utility.ReplacePlaceholders(group.GreetingMessage, userPtr)
-			caption += "\n\nVerification Code: " + code
-		} else {
-			caption = "Welcome! Please type this code to verify: " + code
-		}
+	req := map[string]any{"chat_id": c.Chat().ID}
+	if group.GreetingEnabled && group.GreetingMessage != "" {
+		msg := template.RenderStored(group.GreetingMessage, group.GreetingEntities, template.Markdown, template.Data{Bot: m.Bot, User: u, Chat: c.Chat(), RulesButton: group.RulesButton})
+		msg.Text += "\n\nVerification Code: " + code
+		msg.Apply(req, "text")
+	} else {
+		req["text"] = "Welcome! Please type this code to verify: " + code
+	}
 
-		m.Bot.Raw("sendMessage", map[string]any{
-			"chat_id": c.Chat().ID,
-			"text":    caption,
-		})
+	msg, err := m.Bot.RawMessage("sendMessage", req)
+	if err != nil {
+		return nil
 	}
+	msgKey := "captcha_msg:" + strconv.FormatInt(c.Chat().ID, 10) + ":" + strconv.FormatInt(u.ID, 10)
+	m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Set().Key(msgKey).Value(strconv.FormatInt(msg.ID, 10)).Ex(CaptchaDuration).Build())
 
 	return nil
 }
@@ -178,14 +179,14 @@ func (m *Module) handleCaptchaCommand(c *bot.Context) error {
 		if err != nil {
 			return c.Send("Error: " + err.Error())
 		}
-		m.Logger.Log(targetChat.ID, "settings", "Captcha enabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: targetChat.ID, Category: "settings", Actor: c.Sender(), Text: "Captcha enabled"})
 		return c.Send("CAPTCHA enabled.")
 	case "off":
 		err := m.Store.UpdateGroupCaptcha(targetChat.ID, false)
 		if err != nil {
 			return c.Send("Error: " + err.Error())
 		}
-		m.Logger.Log(targetChat.ID, "settings", "Captcha disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: targetChat.ID, Category: "settings", Actor: c.Sender(), Text: "Captcha disabled"})
 		return c.Send("CAPTCHA disabled.")
 	default:
 		return c.Send("Usage: /captcha <on|off>")
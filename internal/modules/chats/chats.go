@@ -0,0 +1,152 @@
+package chats
+
+import (
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/filters"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/modules/moderation"
+	"lappbot/internal/store"
+)
+
+type Module struct {
+	Bot        *bot.Bot
+	Store      *store.Store
+	Logger     *logging.Module
+	Filters    *filters.FiltersModule
+	Moderation *moderation.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module, f *filters.FiltersModule, mod *moderation.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l, Filters: f, Moderation: mod}
+}
+
+func (m *Module) Register() {
+	m.Bot.Handle("/chats", m.handleChats)
+	m.Bot.Handle("/leave", m.handleLeave)
+	m.Bot.On(bot.EventBotAdded, m.onBotAdded)
+	m.Bot.On(bot.EventBotRemoved, m.onBotRemoved)
+	m.Bot.On(bot.EventMigrated, m.onMigrated)
+}
+
+var onboardingMarkup = &bot.ReplyMarkup{
+	InlineKeyboard: [][]bot.InlineKeyboardButton{
+		{
+			{Text: "Settings", CallbackData: "help_settings"},
+			{Text: "Anti-Spam", CallbackData: "help_antispam"},
+		},
+		{
+			{Text: "Logging", CallbackData: "help_logging"},
+			{Text: "All Commands", CallbackData: "help_main"},
+		},
+	},
+}
+
+func (m *Module) onBotAdded(c *bot.Context) error {
+	chat := c.Chat()
+	if chat.Type != "group" && chat.Type != "supergroup" {
+		return nil
+	}
+
+	var addedBy int64
+	if c.Sender() != nil {
+		addedBy = c.Sender().ID
+	}
+	if err := m.Store.RegisterGroup(chat.ID, chat.Title, addedBy); err != nil {
+		return err
+	}
+
+	return m.Bot.Raw("sendMessage", map[string]any{
+		"chat_id":      chat.ID,
+		"text":         "Thanks for adding me to " + chat.Title + "!\n\nPromote me to admin with delete, restrict and invite rights so I can moderate this group. Use the buttons below to get started.",
+		"reply_markup": onboardingMarkup,
+	})
+}
+
+func (m *Module) onBotRemoved(c *bot.Context) error {
+	return m.Store.SetGroupInactive(c.Chat().ID)
+}
+
+func (m *Module) onMigrated(c *bot.Context) error {
+	oldID, newID := c.Chat().ID, c.Message.MigrateToChatID
+	if c.Message.MigrateFromChatID != 0 {
+		oldID, newID = c.Message.MigrateFromChatID, c.Chat().ID
+	}
+	moved, err := m.Store.MigrateGroup(oldID, newID)
+	if err != nil || !moved {
+		return err
+	}
+	// Messages in the new chat may have been handled before the move
+	// committed and cached an empty rule set under the new ID.
+	for _, id := range []int64{oldID, newID} {
+		m.Filters.Invalidate(id)
+		m.Moderation.InvalidateBlacklist(id)
+		m.Moderation.InvalidateApproved(id)
+	}
+	m.Logger.Emit(logging.Event{Type: "group_migrated", ChatID: newID, Category: "settings", Text: "Old ID: " + strconv.FormatInt(oldID, 10)})
+	return nil
+}
+
+func (m *Module) handleChats(c *bot.Context) error {
+	if c.Sender().ID != m.Bot.Cfg.BotOwnerID {
+		return c.Send("This command is restricted to the bot owner.")
+	}
+
+	groups, err := m.Store.GetGroupRegistry()
+	if err != nil {
+		return c.Send("Failed to fetch groups: " + err.Error())
+	}
+	if len(groups) == 0 {
+		return c.Send("I am not in any groups.")
+	}
+
+	active := 0
+	var sb strings.Builder
+	for _, g := range groups {
+		status := "inactive"
+		if g.Active {
+			status = "active"
+			active++
+		}
+		line := "- " + g.Title + " (" + strconv.FormatInt(g.TelegramID, 10) + ") " + status
+		if g.AddedBy != 0 {
+			line += ", added by " + strconv.FormatInt(g.AddedBy, 10)
+		}
+		line += "\n"
+
+		if sb.Len()+len(line) > 3500 {
+			if err := c.Send(sb.String()); err != nil {
+				return err
+			}
+			sb.Reset()
+		}
+		sb.WriteString(line)
+	}
+	sb.WriteString("\nActive: " + strconv.Itoa(active) + "/" + strconv.Itoa(len(groups)))
+
+	return c.Send(sb.String())
+}
+
+func (m *Module) handleLeave(c *bot.Context) error {
+	if c.Sender().ID != m.Bot.Cfg.BotOwnerID {
+		return c.Send("This command is restricted to the bot owner.")
+	}
+	if len(c.Args) == 0 {
+		return c.Send("Usage: /leave <chat id>")
+	}
+
+	chatID, err := strconv.ParseInt(c.Args[0], 10, 64)
+	if err != nil {
+		return c.Send("Invalid chat ID.")
+	}
+
+	if err := m.Bot.Raw("leaveChat", map[string]any{"chat_id": chatID}); err != nil {
+		return c.Send("Failed to leave chat: " + err.Error())
+	}
+	if err := m.Store.SetGroupInactive(chatID); err != nil {
+		return c.Send("Left chat, but failed to update registry: " + err.Error())
+	}
+	return c.Send("Left chat " + c.Args[0] + ".")
+}
@@ -3,7 +3,9 @@ package clean
 import (
 	"lappbot/internal/bot"
 	"lappbot/internal/store"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-json"
 )
@@ -23,7 +25,8 @@ var adminCommands = map[string]bool{
 	"/pin": true, "/lock": true, "/unlock": true, "/promote": true,
 	"/demote": true, "/approve": true, "/unapprove": true, "/bl": true,
 	"/unbl": true, "/purge": true, "/spurge": true, "/del": true,
-	"/purgefrom": true, "/purgeto": true,
+	"/purgefrom": true, "/purgeto": true, "/purgeuser": true,
+	"/purgetype": true, "/purgetopic": true, "/dban": true,
 	"/dwarn": true, "/swarn": true, "/unwarn": true, "/rmwarn": true,
 	"/resetwarn": true, "/resetallwarns": true,
 	"/newtopic": true, "/renametopic": true, "/closetopic": true,
@@ -32,20 +35,25 @@ var adminCommands = map[string]bool{
 
 var settingsCommands = map[string]bool{
 	"/setlog": true, "/unsetlog": true, "/log": true, "/nolog": true,
-	"/logcategories": true, "/loggroup": true,
+	"/logcategories": true, "/loggroup": true, "/logroute": true, "/evidence": true,
 	"/welcome": true, "/goodbye": true, "/captcha": true, "/antiraid": true,
 	"/raidtime": true, "/raidactiontime": true, "/autoantiraid": true,
 	"/flood": true, "/setflood": true, "/setfloodtimer": true,
 	"/floodmode": true, "/clearflood": true, "/warnlimit": true,
 	"/warnmode": true, "/warntime": true, "/setactiontopic": true,
 	"/cleancommand": true, "/keepcommand": true, "/cleancommandtypes": true, "/cleantypes": true,
+	"/joinrequests": true, "/setwelcome": true, "/setgoodbye": true,
+	"/cleanwelcome": true, "/autodelete": true, "/cleanservice": true, "/keepservice": true,
+	"/welcomemute": true, "/setrules": true, "/resetrules": true, "/privaterules": true, "/setrulesbutton": true,
+	"/setup": true, "/settings": true, "/dashboard": true,
+	"/newapikey": true, "/apikeys": true, "/revokeapikey": true, "/webhooks": true, "/relay": true,
 }
 
 var userCommands = map[string]bool{
 	"/start": true, "/help": true, "/ping": true, "/version": true,
 	"/id": true, "/info": true, "/get": true,
 	"/zalgo": true, "/uwuify": true, "/emojify": true, "/leetify": true,
-	"/warns": true, "/warnings": true,
+	"/warns": true, "/warnings": true, "/rules": true,
 }
 
 var reportCommands = map[string]bool{
@@ -63,13 +71,17 @@ func (m *Module) Register() {
 	m.Bot.Handle("/cleancommand", m.handleCleanCommand)
 	m.Bot.Handle("/keepcommand", m.handleKeepCommand)
 	m.Bot.Handle("/cleancommandtypes", m.handleCleanCommandTypes)
+	m.Bot.Handle("/autodelete", m.handleAutoDelete)
+	m.Bot.Handle("/cleanservice", m.handleCleanService)
+	m.Bot.Handle("/keepservice", m.handleKeepService)
 	m.Bot.Handle("unknown_command", m.handleUnknownCommand)
 	m.Bot.Use(m.checkCleanCommand)
+	m.Bot.Use(m.checkCleanService)
 }
 
 func (m *Module) checkCleanCommand(next bot.HandlerFunc) bot.HandlerFunc {
 	return func(c *bot.Context) error {
-		if c.Message == nil {
+		if c.Message == nil || c.IsEdited || c.Message.ServiceType() != "" {
 			return next(c)
 		}
 
@@ -83,6 +95,10 @@ func (m *Module) checkCleanCommand(next bot.HandlerFunc) bot.HandlerFunc {
 			return next(c)
 		}
 
+		if target.ID == c.Chat().ID && strings.HasPrefix(c.Message.Text, "/") {
+			c.AutoDelete = g.AutoDeleteAfter("commands")
+		}
+
 		var cleanTypes []string
 		json.Unmarshal([]byte(g.CleanCommands), &cleanTypes)
 
@@ -276,3 +292,186 @@ func (m *Module) handleKeepCommand(c *bot.Context) error {
 func (m *Module) handleCleanCommandTypes(c *bot.Context) error {
 	return c.Send("Available command types: all, admin, settings, user, automated, reports, other")
 }
+
+var autoDeleteKinds = []string{"welcome", "goodbye", "captcha", "antiflood", "commands"}
+
+func (m *Module) handleAutoDelete(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, target, "can_delete_messages") {
+		return nil
+	}
+
+	g, err := m.Store.GetGroup(target.ID)
+	if err != nil || g == nil {
+		return c.Send("Error fetching group info.")
+	}
+	delays := g.AutoDeleteDelays()
+
+	args := c.Args
+	if len(args) < 2 {
+		var sb strings.Builder
+		sb.WriteString("Auto-delete delays:\n")
+		for _, kind := range autoDeleteKinds {
+			d := delays[kind]
+			if d == "" {
+				d = "off"
+			}
+			sb.WriteString("- " + kind + ": " + d + "\n")
+		}
+		sb.WriteString("\nUsage: /autodelete <" + strings.Join(autoDeleteKinds, "|") + "> <duration|off>")
+		return c.Send(sb.String())
+	}
+
+	kind := strings.ToLower(args[0])
+	if !slices.Contains(autoDeleteKinds, kind) {
+		return c.Send("Invalid type. Available types: " + strings.Join(autoDeleteKinds, ", "))
+	}
+
+	if strings.EqualFold(args[1], "off") {
+		delete(delays, kind)
+	} else {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 || d > 48*time.Hour {
+			return c.Send("Invalid duration. Use something like 30s, 5m or 1h (max 48h).")
+		}
+		delays[kind] = d.String()
+	}
+
+	if err := m.Store.SetAutoDelete(target.ID, delays); err != nil {
+		return c.Send("Failed to update settings.")
+	}
+
+	if d, ok := delays[kind]; ok {
+		return c.Send("Auto-deleting " + kind + " messages after " + d + ".")
+	}
+	return c.Send("Auto-delete disabled for " + kind + " messages.")
+}
+
+func (m *Module) checkCleanService(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(c *bot.Context) error {
+		if c.Message == nil || c.IsEdited {
+			return next(c)
+		}
+		service := c.Message.ServiceType()
+		if service == "" {
+			return next(c)
+		}
+
+		err := next(c)
+
+		g, gerr := m.Store.GetGroup(c.Chat().ID)
+		if gerr != nil || g == nil {
+			return err
+		}
+
+		var cleanTypes []string
+		json.Unmarshal([]byte(g.CleanService), &cleanTypes)
+		if slices.Contains(cleanTypes, "all") || slices.Contains(cleanTypes, service) {
+			c.Delete()
+		}
+		return err
+	}
+}
+
+func (m *Module) handleCleanService(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, target, "can_delete_messages") {
+		return nil
+	}
+
+	g, err := m.Store.GetGroup(target.ID)
+	if err != nil || g == nil {
+		return c.Send("Error fetching group info.")
+	}
+
+	var cleanTypes []string
+	json.Unmarshal([]byte(g.CleanService), &cleanTypes)
+
+	available := "all, " + strings.Join(bot.ServiceTypes, ", ")
+	if len(c.Args) == 0 {
+		current := "none"
+		if len(cleanTypes) > 0 {
+			current = strings.Join(cleanTypes, ", ")
+		}
+		return c.Send("Cleaning service messages: " + current + "\nUsage: /cleanservice <type> [type...]\nAvailable types: " + available)
+	}
+
+	added := []string{}
+	for _, arg := range c.Args {
+		arg = strings.ToLower(arg)
+		if arg != "all" && !slices.Contains(bot.ServiceTypes, arg) {
+			continue
+		}
+		if !slices.Contains(cleanTypes, arg) {
+			cleanTypes = append(cleanTypes, arg)
+			added = append(added, arg)
+		}
+	}
+
+	if len(added) == 0 {
+		return c.Send("No valid types provided. Available types: " + available)
+	}
+
+	if err := m.Store.SetCleanService(target.ID, cleanTypes); err != nil {
+		return c.Send("Failed to update settings.")
+	}
+
+	return c.Send("Now cleaning service messages: " + strings.Join(added, ", "))
+}
+
+func (m *Module) handleKeepService(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, target, "can_delete_messages") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		return c.Send("Usage: /keepservice <type> [type...]")
+	}
+
+	g, err := m.Store.GetGroup(target.ID)
+	if err != nil || g == nil {
+		return c.Send("Error fetching group info.")
+	}
+
+	var cleanTypes []string
+	json.Unmarshal([]byte(g.CleanService), &cleanTypes)
+
+	removed := []string{}
+	newTypes := []string{}
+	for _, t := range cleanTypes {
+		if slices.ContainsFunc(c.Args, func(arg string) bool { return strings.EqualFold(t, arg) }) {
+			removed = append(removed, t)
+		} else {
+			newTypes = append(newTypes, t)
+		}
+	}
+
+	if len(removed) == 0 {
+		return c.Send("No types removed.")
+	}
+
+	if err := m.Store.SetCleanService(target.ID, newTypes); err != nil {
+		return c.Send("Failed to update settings.")
+	}
+
+	return c.Send("Stopped cleaning service messages: " + strings.Join(removed, ", "))
+}
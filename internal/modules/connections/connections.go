@@ -2,7 +2,6 @@ package connections
 
 import (
 	"strconv"
-	"strings"
 
 	"lappbot/internal/bot"
 	"lappbot/internal/modules/logging"
@@ -25,6 +24,7 @@ func (m *Module) Register() {
 	m.Bot.Handle("/reconnect", m.handleReconnect)
 	m.Bot.Handle("/connection", m.handleConnection)
 	m.Bot.Handle("conn_connect", m.onConnectCallback)
+	m.Bot.HandleStart("connect", m.handleConnect)
 }
 
 func (m *Module) handleConnect(c *bot.Context) error {
@@ -41,7 +41,13 @@ func (m *Module) handleConnect(c *bot.Context) error {
 		if err != nil {
 			return c.Send("Failed to connect.")
 		}
-		m.Logger.Log(c.Chat().ID, "other", "User connected via command in group (ID: "+strconv.FormatInt(c.Chat().ID, 10)+")")
+		m.Logger.Emit(logging.Event{Type: "chat_connected", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender(), Text: "Via command in group"})
+		if url := m.Bot.StartLink("connect", c.Chat().ID); url != "" {
+			markup := &bot.ReplyMarkup{
+				InlineKeyboard: [][]bot.InlineKeyboardButton{{{Text: "Manage in PM", Url: url}}},
+			}
+			return c.Send("Connected to "+c.Chat().Title+".", markup)
+		}
 		return c.Send("Connected to " + c.Chat().Title + ".")
 	}
 
@@ -64,7 +70,7 @@ func (m *Module) handleConnect(c *bot.Context) error {
 			return c.Send("Failed to connect.")
 		}
 		_ = m.Store.AddConnectionHistory(c.Sender().ID, chat.ID, chat.Title)
-		m.Logger.Log(chat.ID, "other", "User connected remotely via PM (ID: "+strconv.FormatInt(c.Sender().ID, 10)+")")
+		m.Logger.Emit(logging.Event{Type: "chat_connected", ChatID: chat.ID, Category: "other", Actor: c.Sender(), Text: "Via PM"})
 		return c.Send("Connected to " + chat.Title + ".")
 	}
 
@@ -78,8 +84,12 @@ func (m *Module) handleConnect(c *bot.Context) error {
 
 	var rows [][]bot.InlineKeyboardButton
 	for _, item := range history {
-		data := "conn_connect|" + strconv.FormatInt(item.ChatID, 10)
-		btn := bot.InlineKeyboardButton{Text: item.ChatTitle, CallbackData: data}
+		btn := m.Bot.CallbackButton(item.ChatTitle, bot.CallbackSession{
+			Endpoint: "conn_connect",
+			Args:     []string{strconv.FormatInt(item.ChatID, 10)},
+			ChatID:   c.Chat().ID,
+			UserID:   c.Sender().ID,
+		})
 		rows = append(rows, []bot.InlineKeyboardButton{btn})
 	}
 	markup.InlineKeyboard = rows
@@ -88,14 +98,11 @@ func (m *Module) handleConnect(c *bot.Context) error {
 }
 
 func (m *Module) onConnectCallback(c *bot.Context) error {
-	data := c.Data()
-	parts := strings.Split(data, "|")
-	if len(parts) < 2 {
-		c.Respond("Invalid data")
-		return nil
+	if c.Session == nil || len(c.Session.Args) < 1 {
+		return c.Respond("This button has expired.")
 	}
 
-	chatIDStr := parts[1]
+	chatIDStr := c.Session.Args[0]
 	chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
 	if err != nil {
 		c.Respond("Invalid chat ID.")
@@ -121,7 +128,7 @@ func (m *Module) onConnectCallback(c *bot.Context) error {
 		return nil
 	}
 
-	m.Logger.Log(chat.ID, "other", "User connected via history button (ID: "+strconv.FormatInt(c.Sender().ID, 10)+")")
+	m.Logger.Emit(logging.Event{Type: "chat_connected", ChatID: chat.ID, Category: "other", Actor: c.Sender(), Text: "Via history button"})
 
 	c.Delete()
 	return c.Send("Connected to " + chat.Title + ".")
@@ -159,7 +166,7 @@ func (m *Module) handleReconnect(c *bot.Context) error {
 	if err != nil {
 		return c.Send("Failed to connect.")
 	}
-	m.Logger.Log(chat.ID, "other", "User reconnected via command (ID: "+strconv.FormatInt(c.Sender().ID, 10)+")")
+	m.Logger.Emit(logging.Event{Type: "chat_connected", ChatID: chat.ID, Category: "other", Actor: c.Sender(), Text: "Reconnected via command"})
 	return c.Send("Reconnected to " + chat.Title + ".")
 }
 
@@ -0,0 +1,342 @@
+package dashboard
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/filters"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/modules/moderation"
+	"lappbot/internal/modules/settings"
+	"lappbot/internal/store"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+type Module struct {
+	Bot        *bot.Bot
+	Store      *store.Store
+	Logger     *logging.Module
+	Filters    *filters.FiltersModule
+	Moderation *moderation.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module, f *filters.FiltersModule, mod *moderation.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l, Filters: f, Moderation: mod}
+}
+
+const basePath = "/app"
+
+type handler func(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error)
+
+type endpoint struct {
+	perm    string
+	handler handler
+}
+
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (m *Module) Register() {
+	if m.Bot.Cfg.WebAppURL == "" {
+		return
+	}
+	m.Bot.Handle("/dashboard", m.handleDashboard)
+	m.Bot.HandleStart("dashboard", m.onStartDashboard)
+	m.Bot.HandleHTTP(basePath, m.serve)
+}
+
+func (m *Module) webAppURL(chatID int64) string {
+	url := strings.TrimSuffix(m.Bot.Cfg.WebAppURL, "/") + basePath
+	if chatID != 0 {
+		url += "?chat_id=" + strconv.FormatInt(chatID, 10)
+	}
+	return url
+}
+
+func (m *Module) sendWebAppButton(c *bot.Context, chatID int64) error {
+	markup := &bot.ReplyMarkup{
+		InlineKeyboard: [][]bot.InlineKeyboardButton{{{Text: "Open Dashboard", WebApp: &bot.WebAppInfo{Url: m.webAppURL(chatID)}}}},
+	}
+	return c.Send("Manage your groups from the dashboard:", markup)
+}
+
+func (m *Module) handleDashboard(c *bot.Context) error {
+	if c.Chat().Type == "private" {
+		target, err := m.Bot.GetTargetChat(c)
+		if err != nil || target.Type == "private" {
+			return m.sendWebAppButton(c, 0)
+		}
+		return m.sendWebAppButton(c, target.ID)
+	}
+
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender()) {
+		return nil
+	}
+	url := m.Bot.StartLink("dashboard", c.Chat().ID)
+	if url == "" {
+		return c.Send("The dashboard is not available right now.")
+	}
+	markup := &bot.ReplyMarkup{
+		InlineKeyboard: [][]bot.InlineKeyboardButton{{{Text: "Open Dashboard", Url: url}}},
+	}
+	return c.Send("The dashboard opens in PM.", markup)
+}
+
+func (m *Module) onStartDashboard(c *bot.Context) error {
+	chatID, err := strconv.ParseInt(c.Args[0], 10, 64)
+	if err != nil {
+		return c.Send("Invalid dashboard link.")
+	}
+	return m.sendWebAppButton(c, chatID)
+}
+
+func (m *Module) routes() map[string]map[string]endpoint {
+	return map[string]map[string]endpoint{
+		"settings": {
+			"GET":  {handler: m.getSettings},
+			"POST": {handler: m.setSetting},
+		},
+		"notes": {
+			"GET":    {handler: m.getNotes},
+			"DELETE": {perm: "can_change_info", handler: m.deleteNote},
+		},
+		"filters": {
+			"GET":    {handler: m.getFilters},
+			"DELETE": {perm: "can_change_info", handler: m.deleteFilter},
+		},
+		"blacklist": {
+			"GET":    {handler: m.getBlacklist},
+			"DELETE": {perm: "can_restrict_members", handler: m.deleteBlacklist},
+		},
+		"warns": {
+			"GET":    {handler: m.getWarns},
+			"DELETE": {perm: "can_restrict_members", handler: m.resetWarns},
+		},
+	}
+}
+
+func (m *Module) serve(ctx *fasthttp.RequestCtx) {
+	path := strings.TrimSuffix(string(ctx.Path()), "/")
+	if path == basePath {
+		ctx.SetContentType("text/html; charset=utf-8")
+		ctx.SetBody(indexHTML)
+		return
+	}
+
+	name, ok := strings.CutPrefix(path, basePath+"/api/")
+	if !ok {
+		ctx.Error("not found", fasthttp.StatusNotFound)
+		return
+	}
+
+	user, err := m.Bot.ValidateInitData(string(ctx.Request.Header.Peek("X-Telegram-Init-Data")))
+	if err != nil {
+		writeError(ctx, fasthttp.StatusUnauthorized, "invalid init data")
+		return
+	}
+
+	if name == "chats" {
+		chats, err := m.listChats(user)
+		if err != nil {
+			writeError(ctx, fasthttp.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(ctx, fasthttp.StatusOK, chats)
+		return
+	}
+
+	ep, ok := m.routes()[name][string(ctx.Method())]
+	if !ok {
+		writeError(ctx, fasthttp.StatusNotFound, "not found")
+		return
+	}
+
+	chatID, err := strconv.ParseInt(string(ctx.QueryArgs().Peek("chat_id")), 10, 64)
+	if err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, "missing chat_id")
+		return
+	}
+	chat := &bot.Chat{ID: chatID}
+
+	perms := []string{}
+	if ep.perm != "" {
+		perms = append(perms, ep.perm)
+	}
+	if !m.Bot.IsAdmin(chat, user, perms...) {
+		writeError(ctx, fasthttp.StatusForbidden, "you are not an admin of this chat")
+		return
+	}
+
+	result, err := ep.handler(ctx, user, chat)
+	if err != nil {
+		status := fasthttp.StatusInternalServerError
+		if e, ok := err.(*apiError); ok {
+			status = e.status
+		}
+		writeError(ctx, status, err.Error())
+		return
+	}
+	writeJSON(ctx, fasthttp.StatusOK, result)
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error("internal error", fasthttp.StatusInternalServerError)
+		return
+	}
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(data)
+}
+
+func writeError(ctx *fasthttp.RequestCtx, status int, message string) {
+	writeJSON(ctx, status, map[string]string{"error": message})
+}
+
+func badRequest(message string) error {
+	return &apiError{status: fasthttp.StatusBadRequest, message: message}
+}
+
+type chatInfo struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+func (m *Module) listChats(user *bot.User) ([]chatInfo, error) {
+	history, err := m.Store.GetConnectionHistory(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	chats := make([]chatInfo, 0, len(history)+1)
+	add := func(id int64, title string) {
+		if id == 0 || seen[id] || !m.Bot.IsAdmin(&bot.Chat{ID: id}, user) {
+			return
+		}
+		seen[id] = true
+		chats = append(chats, chatInfo{ID: id, Title: title})
+	}
+
+	if connected, err := m.Store.GetConnection(user.ID); err == nil && connected != 0 {
+		if g, err := m.Store.GetGroup(connected); err == nil && g != nil {
+			add(connected, g.Title)
+		}
+	}
+	for _, item := range history {
+		add(item.ChatID, item.ChatTitle)
+	}
+	return chats, nil
+}
+
+func (m *Module) getSettings(_ *fasthttp.RequestCtx, _ *bot.User, chat *bot.Chat) (any, error) {
+	group, err := m.Store.GetGroup(chat.ID)
+	if err != nil || group == nil {
+		return nil, &apiError{status: fasthttp.StatusNotFound, message: "group not found"}
+	}
+	return map[string]any{"title": group.Title, "settings": settings.Values(group)}, nil
+}
+
+func (m *Module) setSetting(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error) {
+	var body struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		return nil, badRequest("invalid body")
+	}
+
+	perm := settings.Permission(body.Key)
+	if perm == "" {
+		return nil, badRequest(settings.ErrUnknownSetting.Error())
+	}
+	if !m.Bot.IsAdmin(chat, user, perm) {
+		return nil, &apiError{status: fasthttp.StatusForbidden, message: "missing " + perm + " right"}
+	}
+	if err := settings.Apply(m.Store, chat.ID, body.Key, body.Value); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: chat.ID, Category: "settings", Actor: user, Text: body.Key + " set to " + body.Value + " via dashboard"})
+	return m.getSettings(ctx, user, chat)
+}
+
+func (m *Module) getNotes(_ *fasthttp.RequestCtx, _ *bot.User, chat *bot.Chat) (any, error) {
+	return m.Store.GetNotes(chat.ID)
+}
+
+func (m *Module) deleteNote(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error) {
+	name := string(ctx.QueryArgs().Peek("name"))
+	if name == "" {
+		return nil, badRequest("missing name")
+	}
+	if err := m.Store.DeleteNote(chat.ID, name); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "note_deleted", ChatID: chat.ID, Category: "other", Actor: user, Text: name + " (via dashboard)"})
+	return map[string]bool{"ok": true}, nil
+}
+
+func (m *Module) getFilters(_ *fasthttp.RequestCtx, _ *bot.User, chat *bot.Chat) (any, error) {
+	return m.Store.GetFilters(chat.ID)
+}
+
+func (m *Module) deleteFilter(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error) {
+	trigger := string(ctx.QueryArgs().Peek("trigger"))
+	if trigger == "" {
+		return nil, badRequest("missing trigger")
+	}
+	if err := m.Store.DeleteFilter(chat.ID, trigger); err != nil {
+		return nil, err
+	}
+	m.Filters.Invalidate(chat.ID)
+	m.Logger.Emit(logging.Event{Type: "filter_deleted", ChatID: chat.ID, Category: "other", Actor: user, Text: "Trigger: " + trigger + " (via dashboard)"})
+	return map[string]bool{"ok": true}, nil
+}
+
+func (m *Module) getBlacklist(_ *fasthttp.RequestCtx, _ *bot.User, chat *bot.Chat) (any, error) {
+	return m.Store.GetBlacklist(chat.ID)
+}
+
+func (m *Module) deleteBlacklist(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error) {
+	kind := string(ctx.QueryArgs().Peek("type"))
+	value := string(ctx.QueryArgs().Peek("value"))
+	if kind == "" || value == "" {
+		return nil, badRequest("missing type or value")
+	}
+	if err := m.Store.RemoveBlacklistItem(chat.ID, kind, value); err != nil {
+		return nil, err
+	}
+	m.Moderation.InvalidateBlacklist(chat.ID)
+	m.Logger.Emit(logging.Event{Type: "blacklist_removed", ChatID: chat.ID, Category: "admin", Actor: user, Text: kind + ": " + value + " (via dashboard)"})
+	return map[string]bool{"ok": true}, nil
+}
+
+func (m *Module) getWarns(_ *fasthttp.RequestCtx, _ *bot.User, chat *bot.Chat) (any, error) {
+	return m.Store.GetWarnStats(chat.ID)
+}
+
+func (m *Module) resetWarns(ctx *fasthttp.RequestCtx, user *bot.User, chat *bot.Chat) (any, error) {
+	userID, err := strconv.ParseInt(string(ctx.QueryArgs().Peek("user_id")), 10, 64)
+	if err != nil {
+		return nil, badRequest("missing user_id")
+	}
+	if err := m.Store.ResetWarns(userID, chat.ID); err != nil {
+		return nil, err
+	}
+	m.Logger.Emit(logging.Event{Type: "warns_reset", ChatID: chat.ID, Category: "admin", Actor: user, Target: &bot.User{ID: userID}, Text: "Via dashboard"})
+	return map[string]bool{"ok": true}, nil
+}
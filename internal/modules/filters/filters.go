@@ -8,6 +8,7 @@ import (
 	"lappbot/internal/bot"
 	"lappbot/internal/modules/logging"
 	"lappbot/internal/store"
+	"lappbot/internal/template"
 )
 
 type FiltersCache struct {
@@ -33,6 +34,12 @@ func New(b *bot.Bot, s *store.Store, l *logging.Module) *FiltersModule {
 	}
 }
 
+func (m *FiltersModule) Invalidate(chatID int64) {
+	m.Cache.Lock()
+	delete(m.Cache.Filters, chatID)
+	m.Cache.Unlock()
+}
+
 func (m *FiltersModule) Register() {
 	m.Bot.Handle("/filter", m.handleFilter)
 	m.Bot.Handle("/stop", m.handleStop)
@@ -59,6 +66,7 @@ func (m *FiltersModule) handleFilter(c *bot.Context) error {
 
 	trigger := strings.ToLower(args[0])
 	var response, kind string
+	var entities []bot.MessageEntity
 
 	if c.Message.ReplyTo != nil {
 		msg := c.Message.ReplyTo
@@ -88,30 +96,25 @@ func (m *FiltersModule) handleFilter(c *bot.Context) error {
 			response = msg.Animation.FileID
 		} else {
 			kind = "text"
-			response = msg.Text
-			if response == "" {
-				response = msg.Caption
-			}
+			response, entities = msg.Content()
 		}
 	} else if len(args) >= 2 {
 		kind = "text"
-		response = strings.Join(args[1:], " ")
+		response, entities = c.Message.ContentAfter(2)
 	}
 
 	if response == "" {
 		return c.Send("Please provide a response text or reply to a message.")
 	}
 
-	err = m.Store.AddFilter(target.ID, trigger, response, kind)
+	err = m.Store.AddFilter(target.ID, trigger, response, template.EncodeEntities(entities), kind)
 	if err != nil {
 		return c.Send("Failed to save filter: " + err.Error())
 	}
 
-	m.Cache.Lock()
-	delete(m.Cache.Filters, target.ID)
-	m.Cache.Unlock()
+	m.Invalidate(target.ID)
 
-	m.Logger.Log(target.ID, "other", "Filter added by "+c.Sender().FirstName+"\nTrigger: "+trigger+"\nType: "+kind)
+	m.Logger.Emit(logging.Event{Type: "filter_added", ChatID: target.ID, Category: "other", Actor: c.Sender(), Text: "Trigger: " + trigger + "\nType: " + kind})
 
 	return c.Send("Filter saved!\nTrigger: " + trigger + "\nType: " + kind)
 }
@@ -139,11 +142,9 @@ func (m *FiltersModule) handleStop(c *bot.Context) error {
 		return c.Send("Failed to delete filter: " + err.Error())
 	}
 
-	m.Cache.Lock()
-	delete(m.Cache.Filters, target.ID)
-	m.Cache.Unlock()
+	m.Invalidate(target.ID)
 
-	m.Logger.Log(target.ID, "other", "Filter deleted by "+c.Sender().FirstName+"\nTrigger: "+trigger)
+	m.Logger.Emit(logging.Event{Type: "filter_deleted", ChatID: target.ID, Category: "other", Actor: c.Sender(), Text: "Trigger: " + trigger})
 
 	return c.Send("Filter '" + trigger + "' deleted.")
 }
@@ -171,6 +172,9 @@ func (m *FiltersModule) handleFilters(c *bot.Context) error {
 }
 
 func (m *FiltersModule) handleText(c *bot.Context) error {
+	if c.IsEdited {
+		return nil
+	}
 	text := c.Text()
 	if strings.HasPrefix(text, "/") {
 		return nil
@@ -218,7 +222,9 @@ func (m *FiltersModule) handleText(c *bot.Context) error {
 			case "animation":
 				return m.Bot.Raw("sendAnimation", map[string]any{"chat_id": c.Chat().ID, "animation": f.Response})
 			default:
-				return c.Send(f.Response, "Markdown")
+				req := map[string]any{"chat_id": c.Chat().ID}
+				template.RenderStored(f.Response, f.Entities, template.Markdown, template.Data{Bot: m.Bot, User: c.Sender(), Chat: c.Chat()}).Apply(req, "text")
+				return m.Bot.Raw("sendMessage", req)
 			}
 		}
 	}
@@ -1,11 +1,16 @@
 package greeting
 
 import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+
 	"lappbot/internal/bot"
 	"lappbot/internal/modules/logging"
-	"lappbot/internal/modules/utility"
 	"lappbot/internal/store"
-	"strings"
+	"lappbot/internal/template"
 )
 
 type Module struct {
@@ -21,9 +26,17 @@ func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
 func (m *Module) Register() {
 	m.Bot.Handle("/welcome", m.handleWelcomeCommand)
 	m.Bot.Handle("/goodbye", m.handleGoodbyeCommand)
+	m.Bot.Handle("/setwelcome", m.handleSetWelcome)
+	m.Bot.Handle("/setgoodbye", m.handleSetGoodbye)
+	m.Bot.Handle("/cleanwelcome", m.handleCleanWelcome)
+	m.Bot.Handle("/welcomemute", m.handleWelcomeMute)
+	m.Bot.Handle(welcomeMutePrefix, m.onVerify)
 
-	m.Bot.Handle("new_chat_members", m.OnUserJoined)
-	m.Bot.Handle("left_chat_member", m.OnUserLeft)
+	m.Bot.On(bot.EventJoin, m.OnUserJoined)
+	m.Bot.On(bot.EventLeave, m.OnUserLeft)
+	m.registerSetup()
+
+	go m.Bot.RunQueue(welcomeMuteQueue, 10*time.Second, m.onWelcomeMuteTimeout)
 }
 
 func (m *Module) OnUserJoined(c *bot.Context) error {
@@ -43,13 +56,48 @@ func (m *Module) OnUserJoined(c *bot.Context) error {
 		}
 	}
 
-	if group.GreetingEnabled && group.GreetingMessage != "" {
-		for _, u := range c.Update.Message.NewChatMembers {
-			userPtr := &u
-			c.Send(utility.ReplacePlaceholders(group.GreetingMessage, userPtr), "Markdown")
+	if group.CaptchaEnabled {
+		return nil
+	}
+	if group.AntiraidUntil != nil && group.AntiraidUntil.After(time.Now()) {
+		return nil
+	}
+
+	user := c.ChatMember.NewChatMember.User
+	mode := m.applyWelcomeMute(group, c.Chat(), user)
+
+	hasGreeting := group.GreetingEnabled && (group.GreetingMessage != "" || group.GreetingFileID != "")
+	if !hasGreeting && mode != "strong" {
+		return nil
+	}
+
+	tmpl, kind, fileID := m.welcomeMutePrompt(group, c.Chat(), user), "text", ""
+	if hasGreeting {
+		tmpl = m.renderGreeting(group, c.Chat(), group.GreetingMessage, group.GreetingEntities, group.GreetingType, user)
+		kind, fileID = group.GreetingType, group.GreetingFileID
+	}
+	if mode == "strong" {
+		if tmpl.Markup == nil {
+			tmpl.Markup = &bot.ReplyMarkup{}
 		}
+		tmpl.Markup.InlineKeyboard = append(tmpl.Markup.InlineKeyboard, m.verifyButton(c.Chat().ID, user.ID))
 	}
 
+	msg, err := tmpl.Deliver(m.Bot, c.Chat().ID, kind, fileID)
+	if err != nil {
+		return err
+	}
+	if mode == "strong" {
+		m.scheduleWelcomeMuteKick(group, c.Chat().ID, user.ID, msg.ID)
+	}
+	// Strong-mode welcomes hold the verify button, so they are only cleaned up
+	// once verification finishes or times out.
+	if group.CleanWelcome && mode != "strong" {
+		m.replaceWelcome(c.Chat().ID, msg.ID)
+	}
+	if d := group.AutoDeleteAfter("welcome"); d > 0 && mode != "strong" {
+		m.Bot.DeleteAfter(c.Chat().ID, msg.ID, d)
+	}
 	return nil
 }
 
@@ -62,15 +110,103 @@ func (m *Module) OnUserLeft(c *bot.Context) error {
 		return nil
 	}
 
-	if group.GoodbyeEnabled && group.GoodbyeMessage != "" {
-		if c.Update.Message.LeftChatMember != nil {
-			c.Send(utility.ReplacePlaceholders(group.GoodbyeMessage, c.Update.Message.LeftChatMember), "Markdown")
-		}
+	if !group.GoodbyeEnabled || (group.GoodbyeMessage == "" && group.GoodbyeFileID == "") {
+		return nil
 	}
 
+	msg, err := m.sendGreeting(group, c.Chat(), group.GoodbyeMessage, group.GoodbyeEntities, group.GoodbyeType, group.GoodbyeFileID, c.ChatMember.NewChatMember.User)
+	if err != nil {
+		return err
+	}
+	if d := group.AutoDeleteAfter("goodbye"); d > 0 {
+		m.Bot.DeleteAfter(c.Chat().ID, msg.ID, d)
+	}
 	return nil
 }
 
+func (m *Module) replaceWelcome(chatID, msgID int64) {
+	key := "welcome_msg:" + strconv.FormatInt(chatID, 10)
+	prev, err := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Getset().Key(key).Value(strconv.FormatInt(msgID, 10)).Build()).AsInt64()
+	if err == nil && prev != 0 {
+		m.Bot.Raw("deleteMessage", map[string]any{
+			"chat_id":    chatID,
+			"message_id": prev,
+		})
+	}
+}
+
+func (m *Module) renderGreeting(group *store.Group, chat *bot.Chat, text string, entities json.RawMessage, kind string, user *bot.User) *template.Message {
+	fallback := ""
+	if kind == "" || kind == "text" {
+		fallback = template.Markdown
+	}
+	return template.RenderStored(text, entities, fallback, template.Data{Bot: m.Bot, User: user, Chat: chat, RulesButton: group.RulesButton})
+}
+
+func (m *Module) sendGreeting(group *store.Group, chat *bot.Chat, text string, entities json.RawMessage, kind, fileID string, user *bot.User) (*bot.Message, error) {
+	return m.renderGreeting(group, chat, text, entities, kind, user).Deliver(m.Bot, chat.ID, kind, fileID)
+}
+
+func readGreeting(c *bot.Context, skip int) (string, []bot.MessageEntity, string, string) {
+	content, entities := c.Message.ContentAfter(skip)
+	kind, fileID := "text", ""
+	if reply := c.Message.ReplyTo; reply != nil {
+		kind, fileID = reply.Media()
+		if content == "" && kind != "sticker" && kind != "videonote" {
+			content, entities = reply.Content()
+		}
+	}
+	return content, entities, kind, fileID
+}
+
+func (m *Module) handleSetWelcome(c *bot.Context) error {
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_change_info") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, c.Chat(), "can_change_info") {
+		return nil
+	}
+	return m.setWelcome(c, 1)
+}
+
+func (m *Module) setWelcome(c *bot.Context, skip int) error {
+	content, entities, kind, fileID := readGreeting(c, skip)
+	if content == "" && fileID == "" {
+		return c.Send("Please provide a welcome message or reply to one.")
+	}
+
+	err := m.Store.SetGreetingMessage(c.Chat().ID, content, template.EncodeEntities(entities), kind, fileID)
+	if err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Welcome message set"})
+	return c.Send("Welcome message set.")
+}
+
+func (m *Module) handleSetGoodbye(c *bot.Context) error {
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_change_info") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, c.Chat(), "can_change_info") {
+		return nil
+	}
+	return m.setGoodbye(c, 1)
+}
+
+func (m *Module) setGoodbye(c *bot.Context, skip int) error {
+	content, entities, kind, fileID := readGreeting(c, skip)
+	if content == "" && fileID == "" {
+		return c.Send("Please provide a goodbye message or reply to one.")
+	}
+
+	err := m.Store.SetGoodbyeMessage(c.Chat().ID, content, template.EncodeEntities(entities), kind, fileID)
+	if err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Goodbye message set"})
+	return c.Send("Goodbye message set.")
+}
+
 func (m *Module) handleWelcomeCommand(c *bot.Context) error {
 	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_change_info") {
 		return nil
@@ -81,7 +217,20 @@ func (m *Module) handleWelcomeCommand(c *bot.Context) error {
 
 	args := c.Args
 	if len(args) == 0 {
-		return c.Send("Usage: /welcome <on|off|text> [message]")
+		group, err := m.Store.GetGroup(c.Chat().ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		status := "disabled"
+		if group.GreetingEnabled {
+			status = "enabled"
+		}
+		c.Send("Welcome message is " + status + ".\nUsage: /welcome <on|off|text> [message]\n\nCurrent welcome message:")
+		if group.GreetingMessage == "" && group.GreetingFileID == "" {
+			return c.Send("No welcome message set.")
+		}
+		_, err = m.sendGreeting(group, c.Chat(), group.GreetingMessage, group.GreetingEntities, group.GreetingType, group.GreetingFileID, c.Sender())
+		return err
 	}
 
 	switch args[0] {
@@ -90,39 +239,17 @@ func (m *Module) handleWelcomeCommand(c *bot.Context) error {
 		if err != nil {
 			return c.Send("Error updating setting: " + err.Error())
 		}
-		m.Logger.Log(c.Chat().ID, "settings", "Welcome message enabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Welcome message enabled"})
 		return c.Send("Welcome message enabled.")
 	case "off":
 		err := m.Store.SetGreetingStatus(c.Chat().ID, false)
 		if err != nil {
 			return c.Send("Error updating setting: " + err.Error())
 		}
-		m.Logger.Log(c.Chat().ID, "settings", "Welcome message disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Welcome message disabled"})
 		return c.Send("Welcome message disabled.")
 	case "text":
-		msg := ""
-		if len(args) < 2 {
-			if c.Message.ReplyTo != nil && c.Message.ReplyTo.Text != "" {
-				msg = c.Message.ReplyTo.Text
-			} else {
-				return c.Send("Please provide a welcome message or reply to one.")
-			}
-		} else {
-			for i := 1; i < len(args); i++ {
-				msg += args[i] + " "
-			}
-		}
-		msg = strings.TrimSpace(msg)
-		if msg == "" {
-			return c.Send("Message cannot be empty.")
-		}
-
-		err := m.Store.SetGreetingMessage(c.Chat().ID, msg)
-		if err != nil {
-			return c.Send("Error updating setting: " + err.Error())
-		}
-		m.Logger.Log(c.Chat().ID, "settings", "Welcome message set by "+c.Sender().FirstName)
-		return c.Send("Welcome message set.")
+		return m.setWelcome(c, 2)
 	default:
 		return c.Send("Invalid argument. Use 'on', 'off', or 'text'.")
 	}
@@ -138,7 +265,20 @@ func (m *Module) handleGoodbyeCommand(c *bot.Context) error {
 
 	args := c.Args
 	if len(args) == 0 {
-		return c.Send("Usage: /goodbye <on|off|text> [message]")
+		group, err := m.Store.GetGroup(c.Chat().ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		status := "disabled"
+		if group.GoodbyeEnabled {
+			status = "enabled"
+		}
+		c.Send("Goodbye message is " + status + ".\nUsage: /goodbye <on|off|text> [message]\n\nCurrent goodbye message:")
+		if group.GoodbyeMessage == "" && group.GoodbyeFileID == "" {
+			return c.Send("No goodbye message set.")
+		}
+		_, err = m.sendGreeting(group, c.Chat(), group.GoodbyeMessage, group.GoodbyeEntities, group.GoodbyeType, group.GoodbyeFileID, c.Sender())
+		return err
 	}
 
 	switch args[0] {
@@ -147,40 +287,55 @@ func (m *Module) handleGoodbyeCommand(c *bot.Context) error {
 		if err != nil {
 			return c.Send("Error updating setting: " + err.Error())
 		}
-		m.Logger.Log(c.Chat().ID, "settings", "Goodbye message enabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Goodbye message enabled"})
 		return c.Send("Goodbye message enabled.")
 	case "off":
 		err := m.Store.SetGoodbyeStatus(c.Chat().ID, false)
 		if err != nil {
 			return c.Send("Error updating setting: " + err.Error())
 		}
-		m.Logger.Log(c.Chat().ID, "settings", "Goodbye message disabled by "+c.Sender().FirstName)
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Goodbye message disabled"})
 		return c.Send("Goodbye message disabled.")
 	case "text":
-		msg := ""
-		if len(args) < 2 {
-			if c.Message.ReplyTo != nil && c.Message.ReplyTo.Text != "" {
-				msg = c.Message.ReplyTo.Text
-			} else {
-				return c.Send("Please provide a goodbye message or reply to one.")
-			}
-		} else {
-			for i := 1; i < len(args); i++ {
-				msg += args[i] + " "
-			}
-		}
-		msg = strings.TrimSpace(msg)
-		if msg == "" {
-			return c.Send("Message cannot be empty.")
-		}
-
-		err := m.Store.SetGoodbyeMessage(c.Chat().ID, msg)
-		if err != nil {
-			return c.Send("Error updating setting: " + err.Error())
-		}
-		m.Logger.Log(c.Chat().ID, "settings", "Goodbye message set by "+c.Sender().FirstName)
-		return c.Send("Goodbye message set.")
+		return m.setGoodbye(c, 2)
 	default:
 		return c.Send("Invalid argument. Use 'on', 'off', or 'text'.")
 	}
 }
+
+func (m *Module) handleCleanWelcome(c *bot.Context) error {
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_change_info") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, c.Chat(), "can_delete_messages") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		group, err := m.Store.GetGroup(c.Chat().ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		status := "off"
+		if group.CleanWelcome {
+			status = "on"
+		}
+		return c.Send("Clean welcome is " + status + ".\nUsage: /cleanwelcome <on|off>")
+	}
+
+	var enabled bool
+	switch c.Args[0] {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return c.Send("Usage: /cleanwelcome <on|off>")
+	}
+
+	if err := m.Store.SetCleanWelcome(c.Chat().ID, enabled); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Clean welcome set to " + c.Args[0]})
+	return c.Send("Clean welcome " + c.Args[0] + ".")
+}
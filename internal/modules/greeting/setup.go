@@ -0,0 +1,75 @@
+package greeting
+
+import (
+	"errors"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/template"
+)
+
+func (m *Module) registerSetup() {
+	m.Bot.RegisterConversation(&bot.Conversation{
+		Name:  "welcome",
+		Title: "Welcome",
+		Perm:  "can_change_info",
+		Steps: []bot.Step{
+			{
+				Key:     "enabled",
+				Prompt:  "Should I greet new members?",
+				Options: []string{"on", "off"},
+			},
+			{
+				Key:      "message",
+				Prompt:   "Send me the welcome message. Fillings like {first}, {mention} and {rules} work, and you can send media with a caption.",
+				Optional: true,
+				Validate: validateGreeting,
+			},
+			{
+				Key:     "mute",
+				Prompt:  "Should newcomers be muted? soft blocks media for 24h, strong requires pressing a button.",
+				Options: []string{"off", "soft", "strong"},
+			},
+		},
+		Done: m.finishWelcomeSetup,
+	})
+}
+
+func validateGreeting(c *bot.Context, _ string, values map[string]string) error {
+	if c.Message == nil {
+		return errors.New("Please send the message itself.")
+	}
+	kind, fileID := c.Message.Media()
+	content, entities := c.Message.Content()
+	if content == "" && fileID == "" {
+		return errors.New("The message is empty.")
+	}
+	values["message"] = content
+	values["entities"] = string(template.EncodeEntities(entities))
+	values["kind"] = kind
+	values["file_id"] = fileID
+	return nil
+}
+
+func (m *Module) finishWelcomeSetup(c *bot.Context, target int64, values map[string]string) error {
+	group, err := m.Store.GetGroup(target)
+	if err != nil || group == nil {
+		return c.Send("Error fetching group info.")
+	}
+
+	if err := m.Store.SetGreetingStatus(target, values["enabled"] == "on"); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	if _, ok := values["message"]; ok {
+		err := m.Store.SetGreetingMessage(target, values["message"], []byte(values["entities"]), values["kind"], values["file_id"])
+		if err != nil {
+			return c.Send("Error updating setting: " + err.Error())
+		}
+	}
+	if err := m.Store.SetWelcomeMute(target, values["mute"], group.WelcomeMuteTime); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target, Category: "settings", Actor: c.Sender(), Text: "Welcome settings updated via setup"})
+	return c.Send("Welcome setup complete.\nWelcome: " + values["enabled"] + "\nWelcome mute: " + values["mute"])
+}
@@ -0,0 +1,222 @@
+package greeting
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+	"lappbot/internal/template"
+)
+
+const (
+	welcomeMuteQueue  = "welcomemute"
+	welcomeMutePrefix = "wm_verify"
+	softMuteDuration  = 24 * time.Hour
+)
+
+var softMutePermissions = map[string]bool{
+	"can_send_messages":         true,
+	"can_send_media_messages":   false,
+	"can_send_polls":            false,
+	"can_send_other_messages":   false,
+	"can_add_web_page_previews": false,
+}
+
+var strongMutePermissions = map[string]bool{
+	"can_send_messages":       false,
+	"can_send_media_messages": false,
+	"can_send_polls":          false,
+	"can_send_other_messages": false,
+}
+
+var unmutePermissions = map[string]bool{
+	"can_send_messages":         true,
+	"can_send_media_messages":   true,
+	"can_send_polls":            true,
+	"can_send_other_messages":   true,
+	"can_add_web_page_previews": true,
+	"can_invite_users":          true,
+}
+
+func welcomeMuteTimeout(group *store.Group) time.Duration {
+	d, err := time.ParseDuration(group.WelcomeMuteTime)
+	if err != nil || d <= 0 {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+func (m *Module) applyWelcomeMute(group *store.Group, chat *bot.Chat, user *bot.User) string {
+	mode := group.WelcomeMute
+	if mode != "soft" && mode != "strong" {
+		return "off"
+	}
+	if user.IsBot || m.Bot.Me == nil || !m.Bot.IsAdmin(chat, m.Bot.Me, "can_restrict_members") {
+		return "off"
+	}
+
+	req := map[string]any{
+		"chat_id":     chat.ID,
+		"user_id":     user.ID,
+		"permissions": strongMutePermissions,
+		"until_date":  0,
+	}
+	if mode == "soft" {
+		req["permissions"] = softMutePermissions
+		req["until_date"] = time.Now().Add(softMuteDuration).Unix()
+	}
+	if err := m.Bot.Raw("restrictChatMember", req); err != nil {
+		return "off"
+	}
+	return mode
+}
+
+func (m *Module) verifyButton(chatID, userID int64) []bot.InlineKeyboardButton {
+	return []bot.InlineKeyboardButton{m.Bot.CallbackButton("I'm not a bot", bot.CallbackSession{
+		Endpoint: welcomeMutePrefix,
+		Args:     []string{strconv.FormatInt(userID, 10)},
+		ChatID:   chatID,
+		UserID:   userID,
+	})}
+}
+
+func (m *Module) welcomeMutePrompt(group *store.Group, chat *bot.Chat, user *bot.User) *template.Message {
+	text := "Welcome {mention}! Please press the button below within " + welcomeMuteTimeout(group).String() + " to verify you are human, or you will be removed."
+	return template.Render(text, "", template.Data{Bot: m.Bot, User: user, Chat: chat})
+}
+
+func (m *Module) scheduleWelcomeMuteKick(group *store.Group, chatID, userID, msgID int64) {
+	member := strconv.FormatInt(chatID, 10) + ":" + strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(msgID, 10)
+	m.Bot.Schedule(welcomeMuteQueue, member, time.Now().Add(welcomeMuteTimeout(group)))
+}
+
+func (m *Module) onVerify(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 1 {
+		return c.Respond("This verification has expired.")
+	}
+	userIDStr := c.Session.Args[0]
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+	if c.Sender().ID != userID {
+		return c.Respond("This button is not for you.")
+	}
+
+	chatID := c.Chat().ID
+	msgID := c.Callback.Message.ID
+	member := strconv.FormatInt(chatID, 10) + ":" + userIDStr + ":" + strconv.FormatInt(msgID, 10)
+	if !m.Bot.Unschedule(welcomeMuteQueue, member) {
+		return c.Respond("This verification has expired.")
+	}
+
+	err = m.Bot.Raw("restrictChatMember", map[string]any{
+		"chat_id":     chatID,
+		"user_id":     userID,
+		"permissions": unmutePermissions,
+	})
+	if err != nil {
+		return c.Respond("Failed to unmute you: " + err.Error())
+	}
+
+	var rows [][]bot.InlineKeyboardButton
+	if markup := c.Callback.Message.ReplyMarkup; markup != nil {
+		for _, row := range markup.InlineKeyboard {
+			if len(row) == 1 && row[0].CallbackData == c.Session.Token {
+				continue
+			}
+			rows = append(rows, row)
+		}
+	}
+	req := map[string]any{
+		"chat_id":    chatID,
+		"message_id": msgID,
+	}
+	if len(rows) > 0 {
+		req["reply_markup"] = &bot.ReplyMarkup{InlineKeyboard: rows}
+	}
+	m.Bot.Raw("editMessageReplyMarkup", req)
+	if group, err := m.Store.GetGroup(chatID); err == nil && group != nil && group.CleanWelcome {
+		m.replaceWelcome(chatID, msgID)
+	}
+
+	m.Logger.Emit(logging.Event{Type: "welcome_mute_passed", ChatID: chatID, Category: "automated", Target: c.Sender()})
+	return c.Respond("Verified! You can now chat.")
+}
+
+func (m *Module) onWelcomeMuteTimeout(member string) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 3 {
+		return
+	}
+	chatID, _ := strconv.ParseInt(parts[0], 10, 64)
+	userID, _ := strconv.ParseInt(parts[1], 10, 64)
+	msgID, _ := strconv.ParseInt(parts[2], 10, 64)
+
+	if group, err := m.Store.GetGroup(chatID); err == nil && group != nil && group.CleanWelcome {
+		m.Bot.Raw("deleteMessage", map[string]any{
+			"chat_id":    chatID,
+			"message_id": msgID,
+		})
+	}
+
+	err := m.Bot.Raw("unbanChatMember", map[string]any{
+		"chat_id": chatID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return
+	}
+	m.Logger.Emit(logging.Event{Type: "welcome_mute_kick", ChatID: chatID, Category: "automated", Target: &bot.User{ID: userID}, Reason: "Did not complete verification"})
+}
+
+func (m *Module) handleWelcomeMute(c *bot.Context) error {
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_restrict_members") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, c.Chat(), "can_restrict_members") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		group, err := m.Store.GetGroup(c.Chat().ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		status := "Welcome mute: " + group.WelcomeMute
+		if group.WelcomeMute == "strong" {
+			status += " (" + welcomeMuteTimeout(group).String() + " to verify)"
+		}
+		return c.Send(status + "\nUsage: /welcomemute <off|soft|strong> [time]")
+	}
+
+	mode := strings.ToLower(c.Args[0])
+	if mode != "off" && mode != "soft" && mode != "strong" {
+		return c.Send("Invalid mode. Use: off, soft, strong")
+	}
+
+	duration := "5m"
+	if len(c.Args) > 1 {
+		d, err := time.ParseDuration(c.Args[1])
+		if err != nil || d < time.Minute || d > 24*time.Hour {
+			return c.Send("Invalid time. Use a duration between 1m and 24h, e.g. 5m.")
+		}
+		duration = c.Args[1]
+	}
+
+	if err := m.Store.SetWelcomeMute(c.Chat().ID, mode, duration); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Welcome mute set to " + mode})
+
+	switch mode {
+	case "soft":
+		return c.Send("Welcome mute set to soft: new members cannot send media for 24 hours.")
+	case "strong":
+		return c.Send("Welcome mute set to strong: new members must press a button within " + duration + " or be kicked.")
+	}
+	return c.Send("Welcome mute disabled.")
+}
@@ -0,0 +1,276 @@
+package joinrequest
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+type Module struct {
+	Bot    *bot.Bot
+	Store  *store.Store
+	Logger *logging.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l}
+}
+
+const ChallengeDuration = 5 * time.Minute
+
+func (m *Module) Register() {
+	m.Bot.Handle("/joinrequests", m.handleJoinRequests)
+	m.Bot.Handle("joinreq_answer", m.onAnswer)
+	m.Bot.Handle("joinreq_approve", m.onReview)
+	m.Bot.Handle("joinreq_decline", m.onReview)
+	m.Bot.On(bot.EventJoinRequest, m.onJoinRequest)
+}
+
+func (m *Module) handleJoinRequests(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_invite_users") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, target, "can_invite_users") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		group, err := m.Store.GetGroup(target.ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		return c.Send("Join request mode: " + group.JoinRequestMode + "\nUsage: /joinrequests <off|auto|captcha|manual>")
+	}
+
+	mode := strings.ToLower(c.Args[0])
+	if mode != "off" && mode != "auto" && mode != "captcha" && mode != "manual" {
+		return c.Send("Invalid mode. Use: off, auto, captcha, manual")
+	}
+
+	if err := m.Store.SetJoinRequestMode(target.ID, mode); err != nil {
+		return c.Send("Failed to update settings.")
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target.ID, Category: "settings", Actor: c.Sender(), Text: "Join request mode set to " + mode})
+	return c.Send("Join request mode set to: " + mode)
+}
+
+func (m *Module) onJoinRequest(c *bot.Context) error {
+	req := c.JoinRequest
+	chat := req.Chat
+	user := req.From
+
+	group, err := m.Store.GetGroup(chat.ID)
+	if err != nil || group == nil || group.JoinRequestMode == "" || group.JoinRequestMode == "off" {
+		return nil
+	}
+	if m.Bot.Me == nil || !m.Bot.IsAdmin(chat, m.Bot.Me, "can_invite_users") {
+		return nil
+	}
+
+	if banned, err := m.Store.IsRealmBanned(user.ID, chat.ID); err == nil && banned {
+		m.decline(chat.ID, user.ID)
+		m.Logger.Emit(logging.Event{Type: "join_request_declined", ChatID: chat.ID, Category: "automated", Target: user, Reason: "Realm banned"})
+		return nil
+	}
+
+	if group.AntiraidUntil != nil && group.AntiraidUntil.After(time.Now()) {
+		m.decline(chat.ID, user.ID)
+		m.Logger.Emit(logging.Event{Type: "join_request_declined", ChatID: chat.ID, Category: "automated", Target: user, Reason: "Antiraid active"})
+		return nil
+	}
+
+	switch group.JoinRequestMode {
+	case "auto":
+		if err := m.approve(chat.ID, user.ID); err != nil {
+			return err
+		}
+		m.Logger.Emit(logging.Event{Type: "join_request_approved", ChatID: chat.ID, Category: "automated", Target: user, Reason: "Auto-approve"})
+	case "captcha":
+		if err := m.sendChallenge(chat, user, req.UserChatID); err != nil {
+			return m.postPending(group, user)
+		}
+	case "manual":
+		return m.postPending(group, user)
+	}
+
+	return nil
+}
+
+func (m *Module) sendChallenge(chat *bot.Chat, user *bot.User, userChatID int64) error {
+	a, b := rand.Intn(9)+1, rand.Intn(9)+1
+	answer := a + b
+
+	options := []int{answer}
+	for len(options) < 4 {
+		n := rand.Intn(17) + 2
+		exists := false
+		for _, o := range options {
+			if o == n {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			options = append(options, n)
+		}
+	}
+	rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+
+	key := "joinreq:" + strconv.FormatInt(chat.ID, 10) + ":" + strconv.FormatInt(user.ID, 10)
+	err := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Set().Key(key).Value(strconv.Itoa(answer)).Ex(ChallengeDuration).Build()).Error()
+	if err != nil {
+		return err
+	}
+
+	chatIDStr := strconv.FormatInt(chat.ID, 10)
+	row := make([]bot.InlineKeyboardButton, 0, len(options))
+	for _, o := range options {
+		row = append(row, m.Bot.CallbackButton(strconv.Itoa(o), bot.CallbackSession{
+			Endpoint: "joinreq_answer",
+			Args:     []string{chatIDStr, strconv.Itoa(o)},
+			ChatID:   userChatID,
+			UserID:   user.ID,
+		}))
+	}
+
+	return m.Bot.Raw("sendMessage", map[string]any{
+		"chat_id":      userChatID,
+		"text":         "You requested to join " + chat.Title + ".\nTo verify you are human, answer within 5 minutes:\n\nWhat is " + strconv.Itoa(a) + " + " + strconv.Itoa(b) + "?",
+		"reply_markup": &bot.ReplyMarkup{InlineKeyboard: [][]bot.InlineKeyboardButton{row}},
+	})
+}
+
+func (m *Module) onAnswer(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 2 {
+		return c.Respond("This challenge has expired.")
+	}
+	args := c.Session.Args
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+
+	key := "joinreq:" + args[0] + ":" + strconv.FormatInt(c.Sender().ID, 10)
+	val, err := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Get().Key(key).Build()).ToString()
+	if err != nil || val == "" {
+		c.Respond("This challenge has expired.")
+		group, err := m.Store.GetGroup(chatID)
+		if err != nil || group == nil || m.postPending(group, c.Sender()) != nil {
+			return c.Edit("This challenge has expired and your request could not be forwarded for review. Please request to join again.")
+		}
+		return c.Edit("This challenge has expired. An admin will review your request.")
+	}
+	m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
+
+	user := c.Sender()
+	if args[1] != val {
+		m.decline(chatID, user.ID)
+		m.Logger.Emit(logging.Event{Type: "join_request_declined", ChatID: chatID, Category: "automated", Target: user, Reason: "Failed challenge"})
+		c.Respond("Wrong answer.")
+		return c.Edit("Wrong answer. Your join request has been declined.")
+	}
+
+	if err := m.approve(chatID, user.ID); err != nil {
+		c.Respond("Failed to approve request.")
+		return c.Edit("Verification passed, but I could not approve your request. An admin will review it.")
+	}
+	m.Logger.Emit(logging.Event{Type: "join_request_approved", ChatID: chatID, Category: "automated", Target: user, Reason: "Passed challenge"})
+	c.Respond("Verified!")
+	return c.Edit("Verification successful! Your join request has been approved.")
+}
+
+func (m *Module) postPending(group *store.Group, user *bot.User) error {
+	userIDStr := strconv.FormatInt(user.ID, 10)
+	chatIDStr := strconv.FormatInt(group.TelegramID, 10)
+
+	name := strings.ReplaceAll(user.FirstName, "]", "\\]")
+	name = strings.ReplaceAll(name, "[", "\\[")
+	req := map[string]any{
+		"text":       "New join request from [" + name + "](tg://user?id=" + userIDStr + ") (ID: `" + userIDStr + "`)",
+		"parse_mode": "Markdown",
+	}
+
+	var dest int64
+	switch {
+	case group.ActionTopicID != nil:
+		dest = group.TelegramID
+		req["message_thread_id"] = *group.ActionTopicID
+	case group.LogChannelID != 0:
+		dest = group.LogChannelID
+	default:
+		return nil
+	}
+	req["chat_id"] = dest
+
+	args := []string{chatIDStr, userIDStr}
+	req["reply_markup"] = &bot.ReplyMarkup{
+		InlineKeyboard: [][]bot.InlineKeyboardButton{{
+			m.Bot.CallbackButton("Approve", bot.CallbackSession{Endpoint: "joinreq_approve", Args: args, ChatID: dest}),
+			m.Bot.CallbackButton("Decline", bot.CallbackSession{Endpoint: "joinreq_decline", Args: args, ChatID: dest}),
+		}},
+	}
+
+	return m.Bot.Raw("sendMessage", req)
+}
+
+func (m *Module) onReview(c *bot.Context) error {
+	if c.Session == nil {
+		return c.Respond("This button has expired.")
+	}
+	parts := strings.Split(c.Data(), "|")
+	if len(parts) < 3 {
+		return c.Respond("Invalid data.")
+	}
+	chatID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+	userID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+
+	if !m.Bot.IsAdmin(&bot.Chat{ID: chatID}, c.Sender(), "can_invite_users") {
+		return c.Respond("You must be an admin with invite rights to do this.")
+	}
+
+	if parts[0] == "joinreq_approve" {
+		if err := m.approve(chatID, userID); err != nil {
+			return c.Respond("Failed to approve: " + err.Error())
+		}
+		m.Logger.Emit(logging.Event{Type: "join_request_approved", ChatID: chatID, Category: "admin", Actor: c.Sender(), Target: &bot.User{ID: userID}})
+		c.Respond("Approved.")
+		return c.Edit("Join request from user ID " + parts[2] + " approved by " + c.Sender().FirstName + ".")
+	}
+
+	if err := m.decline(chatID, userID); err != nil {
+		return c.Respond("Failed to decline: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "join_request_declined", ChatID: chatID, Category: "admin", Actor: c.Sender(), Target: &bot.User{ID: userID}})
+	c.Respond("Declined.")
+	return c.Edit("Join request from user ID " + parts[2] + " declined by " + c.Sender().FirstName + ".")
+}
+
+func (m *Module) approve(chatID, userID int64) error {
+	return m.Bot.Raw("approveChatJoinRequest", map[string]any{
+		"chat_id": chatID,
+		"user_id": userID,
+	})
+}
+
+func (m *Module) decline(chatID, userID int64) error {
+	return m.Bot.Raw("declineChatJoinRequest", map[string]any{
+		"chat_id": chatID,
+		"user_id": userID,
+	})
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/store"
+)
+
+var evidenceSources = []string{"blacklist", "antiflood", "warn"}
+
+type Evidence struct {
+	Source    string       `json:"source"`
+	Message   *bot.Message `json:"-"`
+	ChatID    int64        `json:"chat_id,omitempty"`
+	MessageID int64        `json:"message_id,omitempty"`
+}
+
+func evidenceEnabled(group *store.Group, source string) bool {
+	var sources []string
+	json.Unmarshal([]byte(group.EvidenceCategories), &sources)
+	return slices.Contains(sources, source)
+}
+
+func (m *Module) capture(group *store.Group, ev *Event, dest, thread int64) {
+	e := ev.Evidence
+	if e == nil || e.Message == nil || e.MessageID != 0 || !evidenceEnabled(group, e.Source) {
+		return
+	}
+
+	req := map[string]any{
+		"chat_id":              dest,
+		"from_chat_id":         e.Message.Chat.ID,
+		"message_id":           e.Message.ID,
+		"disable_notification": true,
+	}
+	if thread != 0 {
+		req["message_thread_id"] = thread
+	}
+	copied, err := m.Bot.RawMessage("copyMessage", req)
+	if err != nil {
+		copied, err = m.Bot.RawMessage("forwardMessage", req)
+	}
+	if err != nil {
+		log.Warn().Err(err).Int64("chat_id", ev.ChatID).Msg("Failed to capture evidence")
+		return
+	}
+	e.ChatID, e.MessageID = dest, copied.ID
+
+	if d, err := time.ParseDuration(group.EvidenceRetention); err == nil && d > 0 {
+		m.Bot.DeleteAfter(dest, copied.ID, d)
+	}
+}
+
+const evidenceUsage = `Usage:
+/evidence - Show evidence settings
+/evidence on <source...|all> - Copy deleted messages to the log chat
+/evidence off <source...|all> - Stop capturing
+/evidence retention <duration|off> - Delete captured copies after a while (e.g. 72h)
+
+Sources: blacklist, antiflood, warn (/dwarn)`
+
+func (m *Module) handleEvidence(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	g, err := m.Store.GetGroup(target.ID)
+	if err != nil || g == nil {
+		return c.Send("Error fetching group info.")
+	}
+
+	if len(c.Args) == 0 {
+		var sources []string
+		json.Unmarshal([]byte(g.EvidenceCategories), &sources)
+		enabled := "none"
+		if len(sources) > 0 {
+			enabled = strings.Join(sources, ", ")
+		}
+		return c.Send("Evidence capture: " + enabled + "\nRetention: " + g.EvidenceRetention + "\n\nCaptured messages go to the log chat of the matching log category.\n\n" + evidenceUsage)
+	}
+
+	switch strings.ToLower(c.Args[0]) {
+	case "on", "off":
+		return m.setEvidenceSources(c, g, strings.ToLower(c.Args[0]) == "on", c.Args[1:])
+	case "retention":
+		if len(c.Args) < 2 {
+			return c.Send("Usage: /evidence retention <duration|off>")
+		}
+		retention := strings.ToLower(c.Args[1])
+		if retention != "off" {
+			if d, err := time.ParseDuration(retention); err != nil || d <= 0 {
+				return c.Send("Invalid duration.")
+			}
+		}
+		if err := m.Store.SetEvidenceRetention(target.ID, retention); err != nil {
+			return c.Send("Failed to update retention: " + err.Error())
+		}
+		m.Emit(Event{Type: "settings_changed", ChatID: target.ID, Category: "settings", Actor: c.Sender(), Text: "Evidence retention set to " + retention})
+		return c.Send("Evidence retention set to " + retention + ".")
+	}
+	return c.Send(evidenceUsage)
+}
+
+func (m *Module) setEvidenceSources(c *bot.Context, g *store.Group, enable bool, args []string) error {
+	if len(args) == 0 {
+		return c.Send(evidenceUsage)
+	}
+
+	var sources []string
+	json.Unmarshal([]byte(g.EvidenceCategories), &sources)
+
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		if arg == "all" {
+			if enable {
+				sources = slices.Clone(evidenceSources)
+			} else {
+				sources = []string{}
+			}
+			break
+		}
+		if !slices.Contains(evidenceSources, arg) {
+			return c.Send("Unknown source: " + arg + ". Valid sources: " + strings.Join(evidenceSources, ", "))
+		}
+		if enable && !slices.Contains(sources, arg) {
+			sources = append(sources, arg)
+		}
+		if !enable {
+			sources = slices.DeleteFunc(sources, func(s string) bool { return s == arg })
+		}
+	}
+
+	if err := m.Store.SetEvidenceCategories(g.TelegramID, sources); err != nil {
+		return c.Send("Failed to update evidence settings: " + err.Error())
+	}
+	enabled := "none"
+	if len(sources) > 0 {
+		enabled = strings.Join(sources, ", ")
+	}
+	m.Emit(Event{Type: "settings_changed", ChatID: g.TelegramID, Category: "settings", Actor: c.Sender(), Text: "Evidence capture set to " + enabled})
+	return c.Send("Evidence capture: " + enabled)
+}
@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/template"
+)
+
+const ActionEndpoint = "log_action"
+
+var actionLabels = map[string]string{
+	"ban":           "Banned",
+	"unban":         "Unbanned",
+	"kick":          "Kicked",
+	"mute":          "Muted",
+	"unmute":        "Unmuted",
+	"warn":          "Warned",
+	"warn_removed":  "Warn removed",
+	"warns_reset":   "Warns reset",
+	"realm_ban":     "Realm banned",
+	"realm_mute":    "Realm muted",
+	"report":        "Reported",
+	"approve":       "Approved",
+	"unapprove":     "Unapproved",
+	"promote":       "Promoted",
+	"member_join":   "Joined",
+	"member_leave":  "Left",
+	"member_kicked": "Removed",
+	"blacklist":     "Blacklist triggered",
+	"flood":         "Flood detected",
+
+	"join_request_approved": "Join request approved",
+	"join_request_declined": "Join request declined",
+	"captcha_passed":        "Captcha passed",
+	"welcome_mute_passed":   "Welcome verification passed",
+	"welcome_mute_kick":     "Kicked (unverified)",
+	"antiraid_triggered":    "Antiraid triggered",
+	"lock":                  "Group locked",
+	"unlock":                "Group unlocked",
+	"pin":                   "Message pinned",
+	"purge":                 "Messages purged",
+	"message_deleted":       "Message deleted",
+	"custom_title_failed":   "Custom title failed",
+	"blacklist_added":       "Blacklist item added",
+	"blacklist_removed":     "Blacklist item removed",
+	"note_saved":            "Note saved",
+	"note_deleted":          "Note deleted",
+	"notes_cleared":         "Notes cleared",
+	"filter_added":          "Filter added",
+	"filter_deleted":        "Filter deleted",
+	"topic_created":         "Topic created",
+	"topic_renamed":         "Topic renamed",
+	"topic_closed":          "Topic closed",
+	"topic_reopened":        "Topic reopened",
+	"topic_deleted":         "Topic deleted",
+	"chat_connected":        "Connected",
+	"rules_updated":         "Rules updated",
+	"rules_reset":           "Rules reset",
+	"settings_changed":      "Settings changed",
+	"relay_added":           "Relay added",
+	"relay_rotated":         "Relay secret rotated",
+	"relay_removed":         "Relay removed",
+	"webhook_added":         "Webhook added",
+	"webhook_removed":       "Webhook removed",
+	"api_key_created":       "API key created",
+	"api_key_revoked":       "API key revoked",
+	"group_migrated":        "Group migrated",
+}
+
+var quickActions = map[string][2]string{
+	"ban":        {"unban", "Unban"},
+	"realm_ban":  {"unban", "Unban"},
+	"mute":       {"unmute", "Unmute"},
+	"realm_mute": {"unmute", "Unmute"},
+	"warn":       {"rmwarn", "Remove warn"},
+}
+
+func Source(c *bot.Context, chatID int64) int64 {
+	if c.Message == nil || c.Chat().ID != chatID {
+		return 0
+	}
+	if c.Message.ReplyTo != nil {
+		return c.Message.ReplyTo.ID
+	}
+	return c.Message.ID
+}
+
+func MessageLink(chatID, messageID int64) string {
+	id := strconv.FormatInt(chatID, 10)
+	internal, ok := strings.CutPrefix(id, "-100")
+	if !ok || messageID == 0 {
+		return ""
+	}
+	return "https://t.me/c/" + internal + "/" + strconv.FormatInt(messageID, 10)
+}
+
+func userLine(u *bot.User) string {
+	id := strconv.FormatInt(u.ID, 10)
+	if u.FirstName == "" {
+		return "<code>" + id + "</code>"
+	}
+	return template.Mention(u, template.HTML) + " (<code>" + id + "</code>)"
+}
+
+func Format(ev Event, chatTitle string) string {
+	var sb strings.Builder
+	sb.WriteString("<code>[" + strings.ToUpper(ev.Category) + "]</code>")
+	text := template.Escape(ev.Text, template.HTML)
+	if label, ok := actionLabels[ev.Type]; ok {
+		sb.WriteString(" <b>" + label + "</b>")
+	} else if text != "" {
+		sb.WriteString(" " + text)
+		text = ""
+	}
+	if chatTitle != "" {
+		sb.WriteString("\nChat: " + template.Escape(chatTitle, template.HTML))
+	}
+	if ev.Target != nil {
+		sb.WriteString("\nUser: " + userLine(ev.Target))
+	}
+	if ev.Actor != nil {
+		sb.WriteString("\nBy: " + userLine(ev.Actor))
+	}
+	if ev.Duration != "" {
+		sb.WriteString("\nDuration: " + template.Escape(ev.Duration, template.HTML))
+	}
+	if ev.Reason != "" {
+		sb.WriteString("\nReason: " + template.Escape(ev.Reason, template.HTML))
+	}
+	if text != "" {
+		sb.WriteString("\n" + text)
+	}
+	if link := MessageLink(ev.ChatID, ev.MessageID); link != "" {
+		sb.WriteString("\n" + template.Link("Go to message", link, template.HTML))
+	}
+	if ev.Evidence != nil {
+		if link := MessageLink(ev.Evidence.ChatID, ev.Evidence.MessageID); link != "" {
+			sb.WriteString("\n" + template.Link("Evidence", link, template.HTML))
+		}
+	}
+	return sb.String()
+}
+
+func (m *Module) actions(ev Event, dest int64) *bot.ReplyMarkup {
+	action, ok := quickActions[ev.Type]
+	if !ok || ev.Target == nil || ev.Target.ID == 0 {
+		return nil
+	}
+	btn := m.Bot.CallbackButton(action[1], bot.CallbackSession{
+		Endpoint: ActionEndpoint,
+		Args:     []string{action[0], strconv.FormatInt(ev.ChatID, 10), strconv.FormatInt(ev.Target.ID, 10)},
+		ChatID:   dest,
+	})
+	return &bot.ReplyMarkup{InlineKeyboard: [][]bot.InlineKeyboardButton{{btn}}}
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"strconv"
+	"time"
+
+	"lappbot/internal/bot"
+)
+
+const (
+	linkCodeTTL      = 10 * time.Minute
+	failureThreshold = 3
+)
+
+func (m *Module) handleSetLog(c *bot.Context) error {
+	if c.Chat().Type == "channel" {
+		return m.offerLogChat(c)
+	}
+	if origin := c.Message.ForwardOrigin; origin != nil {
+		if origin.Type != "channel" || origin.Chat == nil {
+			return c.Send("Forward the /setlog message from a channel, or use a link code for groups.")
+		}
+		if time.Since(time.Unix(origin.Date, 0)) > linkCodeTTL {
+			return c.Send("That /setlog message is too old. Send /setlog in the channel again and forward the new one.")
+		}
+		target := m.logTarget(c)
+		if target == nil {
+			return nil
+		}
+		if !m.Store.TakeLogLinkPrompt(origin.Chat.ID, origin.MessageID) {
+			return c.Send("That is not a pending /setlog message. Send /setlog in the channel and forward that exact message.")
+		}
+		return m.linkLogChat(c, target, origin.Chat.ID)
+	}
+	if len(c.Args) > 0 {
+		target := m.logTarget(c)
+		if target == nil {
+			return nil
+		}
+		dest, err := m.Store.TakeLogLinkCode(c.Args[0])
+		if err != nil {
+			return c.Send("Invalid or expired code. Send /setlog in the log channel or group to get a new one.")
+		}
+		return m.linkLogChat(c, target, dest)
+	}
+	if c.Chat().Type == "private" {
+		return c.Send("Send /setlog in the channel or group that should receive logs, then forward that message to your group or use the code it gives you.")
+	}
+	if !m.Bot.CheckAdmin(c, c.Chat(), c.Sender(), "can_change_info") {
+		return nil
+	}
+	return m.offerLogChat(c)
+}
+
+func (m *Module) offerLogChat(c *bot.Context) error {
+	code, err := m.Store.CreateLogLinkCode(c.Chat().ID)
+	if err != nil {
+		return c.Send("Failed to create link code: " + err.Error())
+	}
+
+	msg := "This chat is ready to receive logs.\n\n"
+	if c.Chat().Type == "channel" {
+		if err := m.Store.SetLogLinkPrompt(c.Chat().ID, c.Message.ID); err != nil {
+			return c.Send("Failed to create link code: " + err.Error())
+		}
+		msg += "Forward the /setlog message above to the group you want to log, or send <code>/setlog " + code + "</code> there."
+	} else {
+		msg += "Send <code>/setlog " + code + "</code> in the group you want to log."
+	}
+	msg += " You can also send it in PM while connected to that group, or use <code>/logroute &lt;category&gt; " + code + "</code> to route a single category here. The code expires in " + linkCodeTTL.String() + "."
+	return c.Send(msg, "HTML")
+}
+
+// logTarget resolves the group a /setlog link applies to and checks rights
+// before a one-time code or prompt is used up. It replies and returns nil on
+// failure.
+func (m *Module) logTarget(c *bot.Context) *bot.Chat {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		c.Send("Error resolving chat.")
+		return nil
+	}
+	if target.Type == "private" {
+		c.Send("Run this in the group you want to log, or connect to it first with /connect.")
+		return nil
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, target, "can_change_info") {
+		return nil
+	}
+	return target
+}
+
+func (m *Module) linkLogChat(c *bot.Context, target *bot.Chat, dest int64) error {
+	if dest == target.ID {
+		return c.Send("A group can't be its own log chat.")
+	}
+
+	title := target.Title
+	if g, err := m.Store.GetGroup(target.ID); err == nil && g != nil && g.Title != "" {
+		title = g.Title
+	}
+	if err := m.Bot.Raw("sendMessage", map[string]any{
+		"chat_id": dest,
+		"text":    "Logs for " + title + " will be posted here.",
+	}); err != nil {
+		return c.Send("I can't post in that chat: " + err.Error())
+	}
+
+	if err := m.Store.SetLogChannel(target.ID, dest); err != nil {
+		return c.Send("Failed to set log chat.")
+	}
+	m.Store.ClearLogFailures(target.ID, dest)
+	return c.Send("Log chat linked: " + m.chatName(dest) + "\nEnable categories with /log <category>.")
+}
+
+func (m *Module) chatName(chatID int64) string {
+	id := strconv.FormatInt(chatID, 10)
+	chat, err := m.Bot.ResolveChat(id)
+	if err != nil || chat.Title == "" {
+		return id
+	}
+	return chat.Title + " (" + id + ")"
+}
+
+func (m *Module) handleLogGroup(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+
+	msg := ""
+	if g, err := m.Store.GetGroup(target.ID); err == nil && g != nil && target.Type != "private" && target.Type != "channel" {
+		if g.LogChannelID == 0 {
+			msg = "No log chat set. Send /setlog in your log channel to link one.\n"
+		} else {
+			msg = "Logs go to " + m.chatName(g.LogChannelID) + "\n"
+		}
+		if routes, err := m.Store.GetLogRoutes(target.ID); err == nil {
+			for _, r := range routes {
+				msg += "• " + r.Category + " → " + routeName(r.ChatID, r.ThreadID) + "\n"
+			}
+		}
+	}
+
+	groups, err := m.Store.GetGroupsLoggingTo(c.Chat().ID)
+	if err == nil && len(groups) > 0 {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += "Groups logging to this chat:\n"
+		for _, g := range groups {
+			msg += "• " + g.Title + " (" + strconv.FormatInt(g.TelegramID, 10) + ")\n"
+		}
+	}
+
+	if msg == "" {
+		return c.Send("No log chat set.")
+	}
+	return c.Send(msg)
+}
+
+func (m *Module) onSendFailed(groupID, dest int64, err error) {
+	if m.Store.RecordLogFailure(groupID, dest) != failureThreshold {
+		return
+	}
+	m.Bot.Raw("sendMessage", map[string]any{
+		"chat_id": groupID,
+		"text":    "⚠️ I can no longer post to the log chat (" + strconv.FormatInt(dest, 10) + "): " + err.Error() + "\nCheck my permissions there or link a new log chat with /setlog.",
+	})
+}
@@ -3,15 +3,39 @@ package logging
 import (
 	"lappbot/internal/bot"
 	"lappbot/internal/store"
-	"strconv"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/goccy/go-json"
 )
 
+var validCategories = map[string]bool{
+	"settings": true, "admin": true, "user": true,
+	"automated": true, "reports": true, "other": true,
+}
+
+type Event struct {
+	Type      string    `json:"type"`
+	ChatID    int64     `json:"chat_id"`
+	Category  string    `json:"category"`
+	Actor     *bot.User `json:"actor,omitempty"`
+	Target    *bot.User `json:"target,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	MessageID int64     `json:"message_id,omitempty"`
+	Evidence  *Evidence `json:"evidence,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
 type Module struct {
 	Bot   *bot.Bot
 	Store *store.Store
+
+	mu          sync.RWMutex
+	subscribers []func(Event)
 }
 
 func New(b *bot.Bot, s *store.Store) *Module {
@@ -25,59 +49,43 @@ func (m *Module) Register() {
 	m.Bot.Handle("/log", m.handleLogCategory)
 	m.Bot.Handle("/nolog", m.handleNoLogCategory)
 	m.Bot.Handle("/logcategories", m.handleLogCategories)
-}
+	m.Bot.Handle("/logroute", m.handleLogRoute)
+	m.Bot.Handle("/evidence", m.handleEvidence)
 
-func (m *Module) handleLogGroup(c *bot.Context) error {
-	target, err := m.Bot.GetTargetChat(c)
-	if err != nil {
-		return c.Send("Error resolving chat.")
-	}
-
-	g, err := m.Store.GetGroup(target.ID)
-	if err != nil {
-		return c.Send("Error fetching group info.")
-	}
-
-	if g.LogChannelID == 0 {
-		return c.Send("No log group set.")
-	}
-
-	return c.Send("Log group ID: " + strconv.FormatInt(g.LogChannelID, 10))
+	m.Bot.On(bot.EventJoin, m.onMember("member_join"))
+	m.Bot.On(bot.EventLeave, m.onMember("member_leave"))
+	m.Bot.On(bot.EventKicked, m.onMember("member_kicked"))
 }
 
-func (m *Module) handleSetLog(c *bot.Context) error {
-	target, err := m.Bot.GetTargetChat(c)
-	if err != nil {
-		return c.Send("Error resolving chat.")
-	}
-
-	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
-		return nil
-	}
-	if !m.Bot.CheckBotAdmin(c, target, "can_change_info") {
+func (m *Module) onMember(kind string) bot.HandlerFunc {
+	return func(c *bot.Context) error {
+		u := c.ChatMember
+		ev := Event{Type: kind, ChatID: u.Chat.ID, Category: "user", Target: u.NewChatMember.User}
+		if u.From != nil && u.From.ID != u.NewChatMember.User.ID {
+			ev.Actor = u.From
+		}
+		m.Emit(ev)
 		return nil
 	}
+}
 
-	if len(c.Args) == 0 {
-		return c.Send("Usage: /setlog <group_id>")
-	}
+func (m *Module) Subscribe(fn func(Event)) {
+	m.mu.Lock()
+	m.subscribers = append(m.subscribers, fn)
+	m.mu.Unlock()
+}
 
-	groupID, err := strconv.ParseInt(c.Args[0], 10, 64)
-	if err != nil {
-		return c.Send("Invalid group ID. Must be a number.")
+func (m *Module) Emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
 	}
+	m.send(&ev)
 
-	err = m.Store.SetLogChannel(target.ID, groupID)
-	if err != nil {
-		return c.Send("Failed to set log group.")
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, fn := range m.subscribers {
+		fn(ev)
 	}
-
-	m.Bot.Raw("sendMessage", map[string]any{
-		"chat_id": groupID,
-		"text":    "Log group set for group " + target.Title,
-	})
-
-	return c.Send("Log group set to ID: " + strconv.FormatInt(groupID, 10))
 }
 
 func (m *Module) handleUnsetLog(c *bot.Context) error {
@@ -124,11 +132,6 @@ func (m *Module) handleLogCategory(c *bot.Context) error {
 	var categories []string
 	json.Unmarshal([]byte(g.LogCategories), &categories)
 
-	validCategories := map[string]bool{
-		"settings": true, "admin": true, "user": true,
-		"automated": true, "reports": true, "other": true,
-	}
-
 	added := []string{}
 	for _, arg := range args {
 		arg = strings.ToLower(arg)
@@ -212,11 +215,6 @@ func (m *Module) handleNoLogCategory(c *bot.Context) error {
 	var categories []string
 	json.Unmarshal([]byte(g.LogCategories), &categories)
 
-	validCategories := map[string]bool{
-		"settings": true, "admin": true, "user": true,
-		"automated": true, "reports": true, "other": true,
-	}
-
 	removed := []string{}
 
 	if strings.ToLower(args[0]) == "all" {
@@ -274,36 +272,72 @@ func (m *Module) handleLogCategories(c *bot.Context) error {
 	return c.Send("Logged categories: " + strings.Join(categories, ", "))
 }
 
-func (m *Module) Log(chatID int64, category, message string) {
-	group, err := m.Store.GetGroup(chatID)
-	if err != nil || group == nil {
-		return
+func (m *Module) destination(group *store.Group, category string) (int64, int64) {
+	var categories []string
+	if err := json.Unmarshal([]byte(group.LogCategories), &categories); err != nil {
+		return 0, 0
+	}
+	if !slices.Contains(categories, category) {
+		return 0, 0
 	}
 
-	if group.LogChannelID == 0 {
-		return
+	dest, thread := group.LogChannelID, int64(0)
+	if routes, err := m.Store.GetLogRoutes(group.TelegramID); err == nil {
+		for _, r := range routes {
+			if r.Category == category {
+				dest, thread = r.ChatID, r.ThreadID
+			}
+		}
 	}
+	return dest, thread
+}
 
-	var categories []string
-	if err := json.Unmarshal([]byte(group.LogCategories), &categories); err != nil {
+// Capture copies the event's evidence to the log chat right away, so the
+// caller can delete the original before the event itself is emitted.
+func (m *Module) Capture(ev *Event) {
+	if ev.Evidence == nil || ev.Evidence.Message == nil {
+		return
+	}
+	group, err := m.Store.GetGroup(ev.ChatID)
+	if err != nil || group == nil || !evidenceEnabled(group, ev.Evidence.Source) {
 		return
 	}
+	if dest, thread := m.destination(group, ev.Category); dest != 0 {
+		m.capture(group, ev, dest, thread)
+	}
+}
 
-	found := false
-	for _, c := range categories {
-		if c == category {
-			found = true
-			break
-		}
+func (m *Module) send(ev *Event) {
+	group, err := m.Store.GetGroup(ev.ChatID)
+	if err != nil || group == nil {
+		return
 	}
 
-	if !found {
+	dest, thread := m.destination(group, ev.Category)
+	if dest == 0 {
 		return
 	}
 
-	m.Bot.Raw("sendMessage", map[string]any{
-		"chat_id":    group.LogChannelID,
-		"text":       "`[" + strings.ToUpper(category) + "]` " + message,
-		"parse_mode": "Markdown",
-	})
+	m.capture(group, ev, dest, thread)
+
+	req := map[string]any{
+		"chat_id":              dest,
+		"text":                 Format(*ev, group.Title),
+		"parse_mode":           "HTML",
+		"link_preview_options": map[string]any{"is_disabled": true},
+	}
+	if thread != 0 {
+		req["message_thread_id"] = thread
+	}
+	if ev.Evidence != nil && ev.Evidence.MessageID != 0 {
+		req["reply_parameters"] = map[string]any{"message_id": ev.Evidence.MessageID, "allow_sending_without_reply": true}
+	}
+	if markup := m.actions(*ev, dest); markup != nil {
+		req["reply_markup"] = markup
+	}
+	if err := m.Bot.Raw("sendMessage", req); err != nil {
+		m.onSendFailed(ev.ChatID, dest, err)
+		return
+	}
+	m.Store.ClearLogFailures(ev.ChatID, dest)
 }
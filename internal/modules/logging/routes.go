@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"lappbot/internal/bot"
+)
+
+func (m *Module) handleLogRoute(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		return m.listRoutes(c, target)
+	}
+	category := strings.ToLower(c.Args[0])
+	if !validCategories[category] {
+		return c.Send("Invalid category. Valid categories: " + strings.Join(getMapKeys(validCategories), ", "))
+	}
+	if len(c.Args) < 2 {
+		return c.Send(routeUsage)
+	}
+
+	if strings.ToLower(c.Args[1]) == "off" {
+		deleted, err := m.Store.DeleteLogRoute(target.ID, category)
+		if err != nil {
+			return c.Send("Failed to remove log route: " + err.Error())
+		}
+		if !deleted {
+			return c.Send("No route set for " + category + ".")
+		}
+		return c.Send("The " + category + " category now goes to the default log group.")
+	}
+
+	var threadID int64
+	if len(c.Args) > 2 {
+		threadID, err = strconv.ParseInt(c.Args[2], 10, 64)
+		if err != nil || threadID <= 0 {
+			return c.Send("Invalid topic ID.")
+		}
+	}
+
+	chatID, err := m.Store.TakeLogLinkCode(c.Args[1])
+	if err != nil {
+		return c.Send("Invalid or expired code. Send /setlog in the destination chat to get one.")
+	}
+	if chatID == target.ID {
+		return c.Send("A group can't be its own log chat.")
+	}
+
+	req := map[string]any{
+		"chat_id": chatID,
+		"text":    "The " + category + " log for " + target.Title + " will be posted here.",
+	}
+	if threadID != 0 {
+		req["message_thread_id"] = threadID
+	}
+	if err := m.Bot.Raw("sendMessage", req); err != nil {
+		return c.Send("I can't post there: " + err.Error())
+	}
+
+	if err := m.Store.SetLogRoute(target.ID, category, chatID, threadID); err != nil {
+		return c.Send("Failed to set log route: " + err.Error())
+	}
+
+	g, err := m.Store.GetGroup(target.ID)
+	if err == nil && g != nil {
+		var categories []string
+		json.Unmarshal([]byte(g.LogCategories), &categories)
+		if !slices.Contains(categories, category) {
+			m.Store.SetLogCategories(target.ID, append(categories, category))
+		}
+	}
+
+	return c.Send("The " + category + " category is now logged to " + routeName(chatID, threadID) + ".")
+}
+
+const routeUsage = "Usage: /logroute <category> <code> [topic_id] or /logroute <category> off\n\nGet a code by sending /setlog in the chat that should receive the category."
+
+func routeName(chatID, threadID int64) string {
+	name := strconv.FormatInt(chatID, 10)
+	if threadID != 0 {
+		name += " (topic " + strconv.FormatInt(threadID, 10) + ")"
+	}
+	return name
+}
+
+func (m *Module) listRoutes(c *bot.Context, target *bot.Chat) error {
+	routes, err := m.Store.GetLogRoutes(target.ID)
+	if err != nil {
+		return c.Send("Failed to fetch log routes: " + err.Error())
+	}
+	if len(routes) == 0 {
+		return c.Send("No log routes set. All enabled categories go to the default log group.\n\n" + routeUsage)
+	}
+
+	msg := "Log routes:\n"
+	for _, r := range routes {
+		msg += "• " + r.Category + " → " + routeName(r.ChatID, r.ThreadID) + "\n"
+	}
+	return c.Send(msg)
+}
@@ -0,0 +1,183 @@
+package moderation
+
+import (
+	"strconv"
+	"time"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+)
+
+var mutePermissions = map[string]bool{
+	"can_send_messages":       false,
+	"can_send_media_messages": false,
+	"can_send_polls":          false,
+	"can_send_other_messages": false,
+}
+
+var unmutePermissions = map[string]bool{
+	"can_send_messages":         true,
+	"can_send_media_messages":   true,
+	"can_send_polls":            true,
+	"can_send_other_messages":   true,
+	"can_add_web_page_previews": true,
+	"can_invite_users":          true,
+}
+
+func (m *Module) Ban(chatID, userID int64, until time.Time, reason string, by int64) error {
+	m.Store.BanUser(userID, chatID, until, reason, by, "ban")
+
+	req := map[string]any{
+		"chat_id": chatID,
+		"user_id": userID,
+	}
+	if !until.IsZero() {
+		req["until_date"] = until.Unix()
+	}
+	return m.Bot.Raw("banChatMember", req)
+}
+
+func (m *Module) Unban(chatID, userID int64) error {
+	if err := m.Bot.Raw("unbanChatMember", map[string]any{
+		"chat_id":        chatID,
+		"user_id":        userID,
+		"only_if_banned": true,
+	}); err != nil {
+		return err
+	}
+	return m.Store.RemoveBans(userID, chatID)
+}
+
+func (m *Module) Kick(chatID, userID int64) error {
+	return m.Bot.Raw("unbanChatMember", map[string]any{
+		"chat_id": chatID,
+		"user_id": userID,
+	})
+}
+
+func (m *Module) Mute(chatID, userID int64, until time.Time, reason string, by int64) error {
+	m.Store.BanUser(userID, chatID, until, reason, by, "mute")
+
+	var untilDate int64
+	if !until.IsZero() {
+		untilDate = until.Unix()
+	}
+	return m.Bot.Raw("restrictChatMember", map[string]any{
+		"chat_id":     chatID,
+		"user_id":     userID,
+		"permissions": mutePermissions,
+		"until_date":  untilDate,
+	})
+}
+
+func (m *Module) Unmute(chatID, userID int64) error {
+	return m.Bot.Raw("restrictChatMember", map[string]any{
+		"chat_id":     chatID,
+		"user_id":     userID,
+		"permissions": unmutePermissions,
+	})
+}
+
+func (m *Module) RealmBan(target *bot.User, reason string, actor *bot.User) (int, int, error) {
+	groups, err := m.Store.GetAllGroups()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	successCount := 0
+	failCount := 0
+	for _, g := range groups {
+		m.Store.BanUser(target.ID, g.TelegramID, time.Time{}, reason, actor.ID, "realm_ban")
+
+		err := m.Bot.Raw("banChatMember", map[string]any{
+			"chat_id": g.TelegramID,
+			"user_id": target.ID,
+		})
+		if err == nil {
+			m.Logger.Emit(logging.Event{Type: "realm_ban", ChatID: g.TelegramID, Category: "admin", Actor: actor, Target: target, Reason: reason})
+			successCount++
+		} else {
+			failCount++
+		}
+	}
+	return successCount, failCount, nil
+}
+
+func (m *Module) Approve(chatID, userID, by int64) error {
+	if err := m.Store.AddApprovedUser(userID, chatID, by); err != nil {
+		return err
+	}
+
+	m.BlacklistCache.Lock()
+	if m.BlacklistCache.ApprovedUsers[chatID] == nil {
+		m.BlacklistCache.ApprovedUsers[chatID] = make(map[int64]struct{})
+	}
+	m.BlacklistCache.ApprovedUsers[chatID][userID] = struct{}{}
+	m.BlacklistCache.Unlock()
+	return nil
+}
+
+func (m *Module) Unapprove(chatID, userID int64) error {
+	if err := m.Store.RemoveApprovedUser(userID, chatID); err != nil {
+		return err
+	}
+
+	m.BlacklistCache.Lock()
+	delete(m.BlacklistCache.ApprovedUsers[chatID], userID)
+	m.BlacklistCache.Unlock()
+	return nil
+}
+
+func (m *Module) InvalidateBlacklist(chatID int64) {
+	m.BlacklistCache.Lock()
+	delete(m.BlacklistCache.Regexes, chatID)
+	delete(m.BlacklistCache.StickerSets, chatID)
+	delete(m.BlacklistCache.Emojis, chatID)
+	m.BlacklistCache.Unlock()
+}
+
+func (m *Module) InvalidateApproved(chatID int64) {
+	m.BlacklistCache.Lock()
+	delete(m.BlacklistCache.ApprovedUsers, chatID)
+	m.BlacklistCache.Unlock()
+}
+
+func (m *Module) onLogAction(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 3 {
+		return c.Respond("This button has expired.")
+	}
+	action := c.Session.Args[0]
+	chatID, _ := strconv.ParseInt(c.Session.Args[1], 10, 64)
+	userID, _ := strconv.ParseInt(c.Session.Args[2], 10, 64)
+
+	chat := &bot.Chat{ID: chatID}
+	if !m.Bot.IsAdmin(chat, c.Sender(), "can_restrict_members") {
+		return c.Respond("You need to be an admin with can_restrict_members in that group.")
+	}
+
+	var err error
+	kind, done := action, ""
+	switch action {
+	case "unban":
+		err = m.Unban(chatID, userID)
+		done = "Unbanned"
+	case "unmute":
+		err = m.Unmute(chatID, userID)
+		done = "Unmuted"
+	case "rmwarn":
+		err = m.Store.RemoveLastWarn(userID, chatID)
+		kind, done = "warn_removed", "Warn removed"
+	default:
+		return c.Respond("Unknown action.")
+	}
+	if err != nil {
+		return c.Respond("Failed: " + err.Error())
+	}
+
+	m.Bot.Raw("editMessageReplyMarkup", map[string]any{
+		"chat_id":    c.Chat().ID,
+		"message_id": c.Callback.Message.ID,
+	})
+	m.Logger.Emit(logging.Event{Type: kind, ChatID: chatID, Category: "admin", Actor: c.Sender(), Target: &bot.User{ID: userID}, Text: "Via log button."})
+	return c.Respond(done + ".")
+}
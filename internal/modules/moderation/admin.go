@@ -2,7 +2,7 @@ package moderation
 
 import (
 	"lappbot/internal/bot"
-	"strconv"
+	"lappbot/internal/modules/logging"
 	"strings"
 )
 
@@ -23,18 +23,11 @@ func (m *Module) handleApprove(c *bot.Context) error {
 	}
 	target := c.Message.ReplyTo.From
 
-	err = m.Store.AddApprovedUser(target.ID, targetChat.ID, c.Sender().ID)
-	if err != nil {
+	if err := m.Approve(targetChat.ID, target.ID, c.Sender().ID); err != nil {
 		return c.Send("Failed to approve user: " + err.Error())
 	}
 
-	m.BlacklistCache.Lock()
-	if m.BlacklistCache.ApprovedUsers[targetChat.ID] == nil {
-		m.BlacklistCache.ApprovedUsers[targetChat.ID] = make(map[int64]struct{})
-	}
-	m.BlacklistCache.ApprovedUsers[targetChat.ID][target.ID] = struct{}{}
-	m.BlacklistCache.Unlock()
-
+	m.Logger.Emit(logging.Event{Type: "approve", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target})
 	return c.Send(mention(target)+" is now approved.", "Markdown")
 }
 
@@ -55,12 +48,11 @@ func (m *Module) handleUnapprove(c *bot.Context) error {
 	}
 	target := c.Message.ReplyTo.From
 
-	err = m.Store.RemoveApprovedUser(target.ID, targetChat.ID)
-	if err != nil {
+	if err := m.Unapprove(targetChat.ID, target.ID); err != nil {
 		return c.Send("Failed to unapprove user: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Unapproved "+target.FirstName+" (ID: "+strconv.FormatInt(target.ID, 10)+") by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "unapprove", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target})
 	return c.Send("Unapproved " + mention(target) + ".")
 }
 
@@ -112,11 +104,11 @@ func (m *Module) handlePromote(c *bot.Context) error {
 		"custom_title": title,
 	})
 	if err != nil {
-		m.Logger.Log(targetChat.ID, "admin", "Failed to set custom title: "+err.Error())
+		m.Logger.Emit(logging.Event{Type: "custom_title_failed", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: err.Error()})
 	}
 
 	m.Bot.InvalidateAdminCache(targetChat.ID, target.ID)
-	m.Logger.Log(targetChat.ID, "admin", "Promoted "+target.FirstName+" to admin ("+title+") by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "promote", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Text: "Title: " + title})
 	return c.Send(mention(target)+" promoted to admin with title '"+title+"'.", "Markdown")
 }
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
 	"lappbot/internal/store"
 )
 
@@ -53,13 +54,9 @@ func (m *Module) handleBlacklistAdd(c *bot.Context) error {
 		return c.Send("Failed to add blacklist item: " + err.Error())
 	}
 
-	m.BlacklistCache.Lock()
-	delete(m.BlacklistCache.Regexes, targetChat.ID)
-	delete(m.BlacklistCache.StickerSets, targetChat.ID)
-	delete(m.BlacklistCache.Emojis, targetChat.ID)
-	m.BlacklistCache.Unlock()
+	m.InvalidateBlacklist(targetChat.ID)
 
-	m.Logger.Log(targetChat.ID, "admin", "Blacklisted "+kind+": "+value+" (Action: "+action+") by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "blacklist_added", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: kind + ": " + value + " (Action: " + action + ")"})
 	return c.Send("Blacklisted " + kind + ": " + value + " (Action: " + action + ")")
 }
 
@@ -89,13 +86,9 @@ func (m *Module) handleBlacklistRemove(c *bot.Context) error {
 		return c.Send("Failed to remove blacklist item: " + err.Error())
 	}
 
-	m.BlacklistCache.Lock()
-	delete(m.BlacklistCache.Regexes, targetChat.ID)
-	delete(m.BlacklistCache.StickerSets, targetChat.ID)
-	delete(m.BlacklistCache.Emojis, targetChat.ID)
-	m.BlacklistCache.Unlock()
+	m.InvalidateBlacklist(targetChat.ID)
 
-	m.Logger.Log(targetChat.ID, "admin", "Removed "+kind+" from blacklist: "+value+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "blacklist_removed", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: kind + ": " + value})
 	return c.Send("Removed " + kind + " from blacklist: " + value)
 }
 
@@ -253,7 +246,18 @@ func (m *Module) CheckBlacklist(next bot.HandlerFunc) bot.HandlerFunc {
 }
 
 func (m *Module) executeBlacklistAction(c *bot.Context, item store.BlacklistItem) error {
+	ev := logging.Event{
+		Type:     "blacklist",
+		ChatID:   c.Chat().ID,
+		Category: "automated",
+		Target:   c.Sender(),
+		Reason:   "Blacklisted " + item.Type + ": " + item.Value,
+		Text:     "Action: " + item.Action,
+		Evidence: &logging.Evidence{Source: "blacklist", Message: c.Message},
+	}
+	m.Logger.Capture(&ev)
 	c.Delete()
+	m.Logger.Emit(ev)
 
 	switch item.Action {
 	case "delete":
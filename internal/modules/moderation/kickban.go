@@ -2,6 +2,8 @@ package moderation
 
 import (
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -41,11 +43,7 @@ func (m *Module) kickUser(c *bot.Context, silent bool) error {
 		reasonStr = strings.Join(reason, " ")
 	}
 
-	err = m.Bot.Raw("unbanChatMember", map[string]any{
-		"chat_id": targetChat.ID,
-		"user_id": target.ID,
-	})
-	if err != nil {
+	if err := m.Kick(targetChat.ID, target.ID); err != nil {
 		return c.Send("Error kicking user: " + err.Error())
 	}
 
@@ -53,19 +51,23 @@ func (m *Module) kickUser(c *bot.Context, silent bool) error {
 		c.Delete()
 		return nil
 	}
-	m.Logger.Log(targetChat.ID, "admin", "Kicked "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
+	m.Logger.Emit(logging.Event{Type: "kick", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" kicked.\nReason: "+reasonStr, "Markdown")
 }
 
 func (m *Module) handleBan(c *bot.Context) error {
-	return m.banUser(c, false)
+	return m.banUser(c, false, false)
 }
 
 func (m *Module) handleSilentBan(c *bot.Context) error {
-	return m.banUser(c, true)
+	return m.banUser(c, true, false)
+}
+
+func (m *Module) handleDeleteBan(c *bot.Context) error {
+	return m.banUser(c, false, true)
 }
 
-func (m *Module) banUser(c *bot.Context, silent bool) error {
+func (m *Module) banUser(c *bot.Context, silent, deleteMessages bool) error {
 	targetChat, err := m.Bot.GetTargetChat(c)
 	if err != nil {
 		return c.Send("Error resolving chat.")
@@ -77,6 +79,14 @@ func (m *Module) banUser(c *bot.Context, silent bool) error {
 	if !m.Bot.CheckBotAdmin(c, targetChat, "can_restrict_members") {
 		return nil
 	}
+	if deleteMessages {
+		if !m.Bot.CheckAdmin(c, targetChat, c.Sender(), "can_delete_messages") {
+			return nil
+		}
+		if !m.Bot.CheckBotAdmin(c, targetChat, "can_delete_messages") {
+			return nil
+		}
+	}
 	if c.Message.ReplyTo == nil {
 		return c.Send("Reply to a user to ban them.")
 	}
@@ -91,22 +101,25 @@ func (m *Module) banUser(c *bot.Context, silent bool) error {
 		reasonStr = strings.Join(reason, " ")
 	}
 
-	m.Store.BanUser(target.ID, targetChat.ID, time.Time{}, reasonStr, c.Sender().ID, "ban")
-
-	err = m.Bot.Raw("banChatMember", map[string]any{
-		"chat_id": targetChat.ID,
-		"user_id": target.ID,
-	})
-	if err != nil {
+	if err := m.Ban(targetChat.ID, target.ID, time.Time{}, reasonStr, c.Sender().ID); err != nil {
 		return c.Send("Error banning user: " + err.Error())
 	}
 
+	ev := logging.Event{Type: "ban", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, MessageID: logging.Source(c, targetChat.ID)}
+	msg := mention(target) + " banned.\nReason: " + reasonStr
+	if deleteMessages {
+		n := m.deleteRecentMessages(c, targetChat, target.ID)
+		ev.MessageID = 0
+		ev.Text = "Deleted " + strconv.Itoa(n) + " recent messages."
+		msg += "\nDeleted " + strconv.Itoa(n) + " recent messages."
+	}
+
 	if silent {
 		c.Delete()
 		return nil
 	}
-	m.Logger.Log(targetChat.ID, "admin", "Banned "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
-	return c.Send(mention(target)+" banned.\nReason: "+reasonStr, "Markdown")
+	m.Logger.Emit(ev)
+	return c.Send(msg, "Markdown")
 }
 
 func (m *Module) handleUnban(c *bot.Context) error {
@@ -126,16 +139,11 @@ func (m *Module) handleUnban(c *bot.Context) error {
 	}
 	target := c.Message.ReplyTo.From
 
-	err = m.Bot.Raw("unbanChatMember", map[string]any{
-		"chat_id":        targetChat.ID,
-		"user_id":        target.ID,
-		"only_if_banned": true,
-	})
-	if err != nil {
+	if err := m.Unban(targetChat.ID, target.ID); err != nil {
 		return c.Send("Failed to unban user: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Unbanned "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")")
+	m.Logger.Emit(logging.Event{Type: "unban", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" unbanned.", "Markdown")
 }
 
@@ -176,18 +184,11 @@ func (m *Module) handleTimedBan(c *bot.Context) error {
 		reasonStr = strings.Join(args[1:], " ")
 	}
 
-	m.Store.BanUser(target.ID, targetChat.ID, until, reasonStr, c.Sender().ID, "ban")
-
-	err = m.Bot.Raw("banChatMember", map[string]any{
-		"chat_id":    targetChat.ID,
-		"user_id":    target.ID,
-		"until_date": until.Unix(),
-	})
-	if err != nil {
+	if err := m.Ban(targetChat.ID, target.ID, until, reasonStr, c.Sender().ID); err != nil {
 		return c.Send("Error banning user: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Timed Ban for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nDuration: "+durationStr+"\nReason: "+reasonStr)
+	m.Logger.Emit(logging.Event{Type: "ban", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, Duration: durationStr, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" banned for "+durationStr+".\nReason: "+reasonStr, "Markdown")
 }
 
@@ -223,28 +224,27 @@ func (m *Module) handleRealmBan(c *bot.Context) error {
 		reasonStr = strings.Join(reason, " ")
 	}
 
-	groups, err := m.Store.GetAllGroups()
+	successCount, failCount, err := m.RealmBan(target, reasonStr, c.Sender())
 	if err != nil {
 		return c.Send("Failed to fetch groups: " + err.Error())
 	}
 
-	successCount := 0
-	failCount := 0
-
-	for _, g := range groups {
-		m.Store.BanUser(target.ID, g.TelegramID, time.Time{}, reasonStr, c.Sender().ID, "ban")
+	return c.Send("Realm Ban Executed.\nTarget: "+mention(target)+"\nBanned in: "+strconv.Itoa(successCount)+" groups\nFailed in: "+strconv.Itoa(failCount)+" groups\nReason: "+reasonStr, "Markdown")
+}
 
-		err := m.Bot.Raw("banChatMember", map[string]any{
-			"chat_id": g.TelegramID,
-			"user_id": target.ID,
-		})
-		if err == nil {
-			m.Logger.Log(g.TelegramID, "admin", "Realm Ban for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
-			successCount++
-		} else {
-			failCount++
+func (m *Module) deleteRecentMessages(c *bot.Context, targetChat *bot.Chat, userID int64) int {
+	var ids []int64
+	if msgs, err := m.Store.GetIndexedMessages(targetChat.ID); err == nil {
+		for _, msg := range msgs {
+			if msg.UserID == userID {
+				ids = append(ids, msg.ID)
+			}
 		}
 	}
-
-	return c.Send("Realm Ban Executed.\nTarget: "+mention(target)+"\nBanned in: "+strconv.Itoa(successCount)+" groups\nFailed in: "+strconv.Itoa(failCount)+" groups\nReason: "+reasonStr, "Markdown")
+	if c.Chat().ID == targetChat.ID && !slices.Contains(ids, c.Message.ReplyTo.ID) {
+		ids = append(ids, c.Message.ReplyTo.ID)
+	}
+	n := m.Bot.DeleteMessages(targetChat.ID, ids)
+	m.Store.UnindexMessages(targetChat.ID, ids)
+	return n
 }
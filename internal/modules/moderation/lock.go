@@ -2,6 +2,7 @@ package moderation
 
 import (
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
 )
 
 func (m *Module) handleLock(c *bot.Context) error {
@@ -32,7 +33,7 @@ func (m *Module) handleLock(c *bot.Context) error {
 		return c.Send("Failed to lock group.")
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Group locked by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "lock", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender()})
 	return c.Send("Group locked.")
 }
 
@@ -66,6 +67,6 @@ func (m *Module) handleUnlock(c *bot.Context) error {
 		return c.Send("Failed to unlock group.")
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Group unlocked by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "unlock", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender()})
 	return c.Send("Group unlocked.")
 }
@@ -59,6 +59,8 @@ func (m *Module) Register() {
 	m.Bot.Handle("/warnmode", m.handleWarnMode)
 	m.Bot.Handle("/warntime", m.handleWarnTime)
 	m.Bot.Handle("btn_remove_warn", m.onRemoveWarnBtn)
+	m.Bot.Handle(logging.ActionEndpoint, m.onLogAction)
+	m.registerWarnSetup()
 
 	m.Bot.Handle("/kick", m.handleKick)
 	m.Bot.Handle("/skick", m.handleSilentKick)
@@ -66,6 +68,7 @@ func (m *Module) Register() {
 	m.Bot.Handle("/ban", m.handleBan)
 	m.Bot.Handle("/unban", m.handleUnban)
 	m.Bot.Handle("/sban", m.handleSilentBan)
+	m.Bot.Handle("/dban", m.handleDeleteBan)
 	m.Bot.Handle("/tban", m.handleTimedBan)
 	m.Bot.Handle("/rban", m.handleRealmBan)
 
@@ -2,6 +2,7 @@ package moderation
 
 import (
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
 	"strconv"
 	"strings"
 	"time"
@@ -41,22 +42,7 @@ func (m *Module) muteUser(c *bot.Context, silent bool) error {
 		reasonStr = strings.Join(reason, " ")
 	}
 
-	m.Store.BanUser(target.ID, targetChat.ID, time.Time{}, reasonStr, c.Sender().ID, "mute")
-
-	permissions := map[string]bool{
-		"can_send_messages":       false,
-		"can_send_media_messages": false,
-		"can_send_polls":          false,
-		"can_send_other_messages": false,
-	}
-
-	err = m.Bot.Raw("restrictChatMember", map[string]any{
-		"chat_id":     targetChat.ID,
-		"user_id":     target.ID,
-		"permissions": permissions,
-		"until_date":  0,
-	})
-	if err != nil {
+	if err := m.Mute(targetChat.ID, target.ID, time.Time{}, reasonStr, c.Sender().ID); err != nil {
 		return c.Send("Error muting user: " + err.Error())
 	}
 
@@ -64,7 +50,7 @@ func (m *Module) muteUser(c *bot.Context, silent bool) error {
 		c.Delete()
 		return nil
 	}
-	m.Logger.Log(targetChat.ID, "admin", "Muted "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
+	m.Logger.Emit(logging.Event{Type: "mute", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" muted.\nReason: "+reasonStr, "Markdown")
 }
 
@@ -85,25 +71,11 @@ func (m *Module) handleUnmute(c *bot.Context) error {
 	}
 	target := c.Message.ReplyTo.From
 
-	permissions := map[string]bool{
-		"can_send_messages":         true,
-		"can_send_media_messages":   true,
-		"can_send_polls":            true,
-		"can_send_other_messages":   true,
-		"can_add_web_page_previews": true,
-		"can_invite_users":          true,
-	}
-
-	err = m.Bot.Raw("restrictChatMember", map[string]any{
-		"chat_id":     targetChat.ID,
-		"user_id":     target.ID,
-		"permissions": permissions,
-	})
-	if err != nil {
+	if err := m.Unmute(targetChat.ID, target.ID); err != nil {
 		return c.Send("Failed to unmute user: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Unmuted "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")")
+	m.Logger.Emit(logging.Event{Type: "unmute", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" unmuted.", "Markdown")
 }
 
@@ -144,26 +116,11 @@ func (m *Module) handleTimedMute(c *bot.Context) error {
 		reasonStr = strings.Join(args[1:], " ")
 	}
 
-	m.Store.BanUser(target.ID, targetChat.ID, until, reasonStr, c.Sender().ID, "mute")
-
-	permissions := map[string]bool{
-		"can_send_messages":       false,
-		"can_send_media_messages": false,
-		"can_send_polls":          false,
-		"can_send_other_messages": false,
-	}
-
-	err = m.Bot.Raw("restrictChatMember", map[string]any{
-		"chat_id":     targetChat.ID,
-		"user_id":     target.ID,
-		"permissions": permissions,
-		"until_date":  until.Unix(),
-	})
-	if err != nil {
+	if err := m.Mute(targetChat.ID, target.ID, until, reasonStr, c.Sender().ID); err != nil {
 		return c.Send("Error muting user: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Timed Mute for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nDuration: "+durationStr+"\nReason: "+reasonStr)
+	m.Logger.Emit(logging.Event{Type: "mute", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, Duration: durationStr, MessageID: logging.Source(c, targetChat.ID)})
 	return c.Send(mention(target)+" muted for "+durationStr+".\nReason: "+reasonStr, "Markdown")
 }
 
@@ -207,24 +164,17 @@ func (m *Module) handleRealmMute(c *bot.Context) error {
 	successCount := 0
 	failCount := 0
 
-	permissions := map[string]bool{
-		"can_send_messages":       false,
-		"can_send_media_messages": false,
-		"can_send_polls":          false,
-		"can_send_other_messages": false,
-	}
-
 	for _, g := range groups {
 		m.Store.BanUser(target.ID, g.TelegramID, time.Time{}, reasonStr, c.Sender().ID, "mute")
 
 		err := m.Bot.Raw("restrictChatMember", map[string]any{
 			"chat_id":     g.TelegramID,
 			"user_id":     target.ID,
-			"permissions": permissions,
+			"permissions": mutePermissions,
 			"until_date":  0,
 		})
 		if err == nil {
-			m.Logger.Log(g.TelegramID, "admin", "Realm Mute for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
+			m.Logger.Emit(logging.Event{Type: "realm_mute", ChatID: g.TelegramID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr, MessageID: logging.Source(c, g.TelegramID)})
 			successCount++
 		} else {
 			failCount++
@@ -2,6 +2,7 @@ package moderation
 
 import (
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
 )
 
 func (m *Module) handlePin(c *bot.Context) error {
@@ -28,7 +29,7 @@ func (m *Module) handlePin(c *bot.Context) error {
 		return c.Send("Failed to pin message.")
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Message pinned by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "pin", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender()})
 
 	return nil
 }
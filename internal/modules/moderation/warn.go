@@ -1,7 +1,9 @@
 package moderation
 
 import (
+	"errors"
 	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
 	"strconv"
 	"strings"
 	"time"
@@ -54,15 +56,19 @@ func (m *Module) warnUser(c *bot.Context, deleteMessage, silent bool) error {
 		return c.Send("Error adding warn: " + err.Error())
 	}
 
-	m.Logger.Log(targetChat.ID, "admin", "Warned "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")\nReason: "+reasonStr)
-
+	ev := logging.Event{Type: "warn", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reasonStr}
 	if deleteMessage {
+		ev.Evidence = &logging.Evidence{Source: "warn", Message: c.Message.ReplyTo}
+		m.Logger.Capture(&ev)
 		m.Bot.Raw("deleteMessage", map[string]any{
 			"chat_id":    targetChat.ID,
 			"message_id": c.Message.ReplyTo.ID,
 		})
 		c.Delete()
+	} else {
+		ev.MessageID = logging.Source(c, targetChat.ID)
 	}
+	m.Logger.Emit(ev)
 
 	return m.checkPunish(c, targetChat, target, reasonStr, silent)
 }
@@ -113,34 +119,34 @@ func (m *Module) checkPunish(c *bot.Context, targetChat *bot.Chat, target *bot.U
 
 		switch actType {
 		case "ban":
-			err = m.Bot.Raw("banChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID})
-			m.Logger.Log(c.Chat().ID, "admin", "Warn removed from "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") by "+c.Sender().FirstName)
+			err = m.Bot.Raw("banChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID})
+			m.Logger.Emit(logging.Event{Type: "ban", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Banned."
 		case "kick":
-			err = m.Bot.Raw("unbanChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID})
-			m.Logger.Log(c.Chat().ID, "admin", "Kicked "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") for reaching warn limit.")
+			err = m.Bot.Raw("unbanChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID})
+			m.Logger.Emit(logging.Event{Type: "kick", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Kicked."
 		case "mute":
 			permissions := map[string]bool{"can_send_messages": false}
-			err = m.Bot.Raw("restrictChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID, "permissions": permissions, "until_date": 0})
-			m.Logger.Log(c.Chat().ID, "admin", "Muted "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") for reaching warn limit.")
+			err = m.Bot.Raw("restrictChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID, "permissions": permissions, "until_date": 0})
+			m.Logger.Emit(logging.Event{Type: "mute", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Muted."
 		case "tban":
 			d, _ := time.ParseDuration(duration)
 			until := time.Now().Add(d).Unix()
-			err = m.Bot.Raw("banChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID, "until_date": until})
-			m.Logger.Log(c.Chat().ID, "admin", "Warns reset for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") by "+c.Sender().FirstName)
+			err = m.Bot.Raw("banChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID, "until_date": until})
+			m.Logger.Emit(logging.Event{Type: "ban", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Duration: duration, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Banned for " + duration + "."
 		case "tmute":
 			d, _ := time.ParseDuration(duration)
 			until := time.Now().Add(d).Unix()
 			permissions := map[string]bool{"can_send_messages": false}
-			err = m.Bot.Raw("restrictChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID, "permissions": permissions, "until_date": until})
-			m.Logger.Log(c.Chat().ID, "admin", "Timed Mute for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") for reaching warn limit.\nDuration: "+duration)
+			err = m.Bot.Raw("restrictChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID, "permissions": permissions, "until_date": until})
+			m.Logger.Emit(logging.Event{Type: "mute", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Duration: duration, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Muted for " + duration + "."
 		default:
-			err = m.Bot.Raw("unbanChatMember", map[string]any{"chat_id": c.Chat().ID, "user_id": target.ID})
-			m.Logger.Log(c.Chat().ID, "admin", "Kicked "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+") for reaching warn limit (Default).")
+			err = m.Bot.Raw("unbanChatMember", map[string]any{"chat_id": targetChat.ID, "user_id": target.ID})
+			m.Logger.Emit(logging.Event{Type: "kick", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Target: target, Reason: reason, Text: "Warn limit reached.", MessageID: logging.Source(c, targetChat.ID)})
 			msg += "\nAction: Kicked (Default)."
 		}
 
@@ -149,10 +155,11 @@ func (m *Module) checkPunish(c *bot.Context, targetChat *bot.Chat, target *bot.U
 		}
 	} else {
 		markup := &bot.ReplyMarkup{}
-		btn := bot.InlineKeyboardButton{
-			Text:         "Remove Warn",
-			CallbackData: "btn_remove_warn|" + strconv.FormatInt(target.ID, 10),
-		}
+		btn := m.Bot.CallbackButton("Remove Warn", bot.CallbackSession{
+			Endpoint: "btn_remove_warn",
+			Args:     []string{strconv.FormatInt(target.ID, 10)},
+			ChatID:   c.Chat().ID,
+		})
 		markup.InlineKeyboard = [][]bot.InlineKeyboardButton{{btn}}
 
 		if !silent {
@@ -196,7 +203,7 @@ func (m *Module) handleRmWarn(c *bot.Context) error {
 		return c.Send("Error removing warn: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "admin", "All warns reset by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "warn_removed", ChatID: c.Chat().ID, Category: "admin", Actor: c.Sender(), Target: c.Message.ReplyTo.From, MessageID: logging.Source(c, c.Chat().ID)})
 	return c.Send("Last warn removed for " + targetName + ".")
 }
 
@@ -219,7 +226,7 @@ func (m *Module) handleResetWarns(c *bot.Context) error {
 	if err != nil {
 		return c.Send("Error resetting warns.")
 	}
-	m.Logger.Log(c.Chat().ID, "admin", "Reset user warns for "+mention(target)+" (ID: "+strconv.FormatInt(target.ID, 10)+")")
+	m.Logger.Emit(logging.Event{Type: "warns_reset", ChatID: c.Chat().ID, Category: "admin", Actor: c.Sender(), Target: target, MessageID: logging.Source(c, c.Chat().ID)})
 	return c.Send("Warns reset for "+mention(target)+".", "Markdown")
 }
 
@@ -234,7 +241,7 @@ func (m *Module) handleResetAllWarns(c *bot.Context) error {
 	if err != nil {
 		return c.Send("Error resetting all warns: " + err.Error())
 	}
-	m.Logger.Log(c.Chat().ID, "admin", "Reset all warns in chat")
+	m.Logger.Emit(logging.Event{Type: "warns_reset", ChatID: c.Chat().ID, Category: "admin", Actor: c.Sender(), MessageID: logging.Source(c, c.Chat().ID)})
 	return c.Send("All warnings in this chat have been reset.")
 }
 
@@ -353,12 +360,11 @@ func (m *Module) onRemoveWarnBtn(c *bot.Context) error {
 		return nil
 	}
 
-	parts := strings.Split(c.Data(), "|")
-	if len(parts) < 2 {
-		return c.Respond("Invalid data.")
+	if c.Session == nil || len(c.Session.Args) < 1 {
+		return c.Respond("This button has expired.")
 	}
 
-	targetIDStr := parts[1]
+	targetIDStr := c.Session.Args[0]
 	targetID, _ := strconv.ParseInt(targetIDStr, 10, 64)
 
 	err := m.Store.RemoveLastWarn(targetID, c.Chat().ID)
@@ -367,6 +373,68 @@ func (m *Module) onRemoveWarnBtn(c *bot.Context) error {
 	}
 
 	c.Delete()
-	m.Logger.Log(c.Chat().ID, "admin", "Removed warn for user ID "+strconv.FormatInt(targetID, 10)+" via button")
+	m.Logger.Emit(logging.Event{Type: "warn_removed", ChatID: c.Chat().ID, Category: "admin", Actor: c.Sender(), Target: &bot.User{ID: targetID}})
 	return c.Respond("Warn removed.")
 }
+
+func (m *Module) registerWarnSetup() {
+	m.Bot.RegisterConversation(&bot.Conversation{
+		Name:  "warns",
+		Title: "Warnings",
+		Perm:  "can_restrict_members",
+		Steps: []bot.Step{
+			{
+				Key:    "limit",
+				Prompt: "How many warns before action is taken?",
+				Validate: func(_ *bot.Context, input string, values map[string]string) error {
+					limit, err := strconv.Atoi(strings.TrimSpace(input))
+					if err != nil || limit < 1 {
+						return errors.New("Invalid limit.")
+					}
+					values["limit"] = strconv.Itoa(limit)
+					return nil
+				},
+			},
+			{
+				Key:     "action",
+				Prompt:  "What should happen when the limit is reached?",
+				Options: []string{"kick", "ban", "mute", "tban", "tmute"},
+			},
+			{
+				Key:      "duration",
+				Prompt:   "For tban or tmute, how long should the punishment last? (e.g. 1h)",
+				Optional: true,
+				Validate: func(_ *bot.Context, input string, values map[string]string) error {
+					input = strings.TrimSpace(input)
+					if _, err := time.ParseDuration(input); err != nil {
+						return errors.New("Invalid duration.")
+					}
+					values["duration"] = input
+					return nil
+				},
+			},
+		},
+		Done: m.finishWarnSetup,
+	})
+}
+
+func (m *Module) finishWarnSetup(c *bot.Context, target int64, values map[string]string) error {
+	limit, _ := strconv.Atoi(values["limit"])
+	action := values["action"]
+	if action == "tban" || action == "tmute" {
+		duration := values["duration"]
+		if duration == "" {
+			duration = "1h"
+		}
+		action += " " + duration
+	}
+
+	if err := m.Store.SetWarnLimit(target, limit); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	if err := m.Store.SetWarnAction(target, action); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target, Category: "settings", Actor: c.Sender(), Text: "Warn settings updated via setup"})
+	return c.Send("Warnings setup complete.\nLimit: " + values["limit"] + "\nAction: " + action)
+}
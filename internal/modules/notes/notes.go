@@ -1,11 +1,13 @@
 package notes
 
 import (
+	"strconv"
 	"strings"
 
 	"lappbot/internal/bot"
 	"lappbot/internal/modules/logging"
 	"lappbot/internal/store"
+	"lappbot/internal/template"
 )
 
 type Module struct {
@@ -27,11 +29,16 @@ func (m *Module) Register() {
 	m.Bot.Handle("/clearall", m.handleClearAll)
 	m.Bot.Handle("/privatenotes", m.handlePrivateNotes)
 	m.Bot.Handle("get_note_pm", m.onGetNotePM)
+	m.Bot.Handle(template.NoteButtonPrefix, m.onNoteButton)
+	m.Bot.HandleStart("note", m.onStartNote)
 	m.Bot.Use(m.shortcutMiddleware)
 }
 
 func (m *Module) shortcutMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
 	return func(c *bot.Context) error {
+		if c.IsEdited {
+			return next(c)
+		}
 		text := c.Text()
 		if len(text) > 1 && strings.HasPrefix(text, "#") {
 			name := strings.ToLower(text[1:])
@@ -58,65 +65,16 @@ func (m *Module) handleSave(c *bot.Context) error {
 		return c.Send("Usage: /save <name> [content]")
 	}
 	name := strings.ToLower(args[0])
-	content := ""
-	if len(args) > 1 {
-		content = strings.Join(args[1:], " ")
-	}
+	content, entities := c.Message.ContentAfter(2)
 
 	noteType := "text"
 	fileID := ""
 
 	if c.Message.ReplyTo != nil {
 		reply := c.Message.ReplyTo
-		if len(reply.Photo) > 0 {
-			noteType = "photo"
-			fileID = reply.Photo[0].FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else if reply.Video != nil {
-			noteType = "video"
-			fileID = reply.Video.FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else if reply.VideoNote != nil {
-			noteType = "videonote"
-			fileID = reply.VideoNote.FileID
-		} else if reply.Animation != nil {
-			noteType = "animation"
-			fileID = reply.Animation.FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else if reply.Sticker != nil {
-			noteType = "sticker"
-			fileID = reply.Sticker.FileID
-		} else if reply.Voice != nil {
-			noteType = "voice"
-			fileID = reply.Voice.FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else if reply.Audio != nil {
-			noteType = "audio"
-			fileID = reply.Audio.FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else if reply.Document != nil {
-			noteType = "document"
-			fileID = reply.Document.FileID
-			if content == "" {
-				content = reply.Caption
-			}
-		} else {
-			if content == "" {
-				content = reply.Text
-				if content == "" {
-					content = reply.Caption
-				}
-			}
+		noteType, fileID = reply.Media()
+		if content == "" && noteType != "sticker" && noteType != "videonote" {
+			content, entities = reply.Content()
 		}
 	}
 
@@ -124,11 +82,11 @@ func (m *Module) handleSave(c *bot.Context) error {
 		return c.Send("You need to provide content or reply to a message to save a note.")
 	}
 
-	err = m.Store.SaveNote(target.ID, name, content, noteType, fileID, c.Sender().ID)
+	err = m.Store.SaveNote(target.ID, name, content, template.EncodeEntities(entities), noteType, fileID, c.Sender().ID)
 	if err != nil {
 		return c.Send("Failed to save note.")
 	}
-	m.Logger.Log(target.ID, "other", "Note saved: "+name)
+	m.Logger.Emit(logging.Event{Type: "note_saved", ChatID: target.ID, Category: "other", Actor: c.Sender(), Text: name})
 	return c.Send("Note `"+name+"` saved.", "Markdown")
 }
 
@@ -160,32 +118,35 @@ func (m *Module) sendNoteResponse(c *bot.Context, note *store.Note) error {
 		return c.Send("Error resolving chat.")
 	}
 
+	data := template.Data{Bot: m.Bot, User: c.Sender(), Chat: target}
 	group, err := m.Store.GetGroup(target.ID)
 	if err != nil || group == nil {
-		return m.deliverNote(c.Chat().ID, note)
+		return m.deliverNote(c.Chat().ID, note, data)
 	}
+	data.RulesButton = group.RulesButton
 
-	if group.NotesPrivate {
+	if group.NotesPrivate && c.Chat().Type != "private" {
 		markup := &bot.ReplyMarkup{}
-		btn := bot.InlineKeyboardButton{
-			Text:         "Click to get note",
-			CallbackData: "get_note_pm|" + note.Name,
+		btn := m.Bot.CallbackButton("Click to get note", bot.CallbackSession{
+			Endpoint: "get_note_pm",
+			Args:     []string{note.Name},
+			ChatID:   c.Chat().ID,
+		})
+		if url := m.Bot.StartLink("note", target.ID, note.Name); url != "" {
+			btn = bot.InlineKeyboardButton{Text: "Click to get note", Url: url}
 		}
 		markup.InlineKeyboard = [][]bot.InlineKeyboardButton{{btn}}
 		return c.Send("Click the button below to view note `"+note.Name+"`.", markup, "Markdown")
 	}
 
-	return m.deliverNote(c.Chat().ID, note)
+	return m.deliverNote(c.Chat().ID, note, data)
 }
 
 func (m *Module) onGetNotePM(c *bot.Context) error {
-	data := c.Data()
-	parts := strings.Split(data, "|")
-	if len(parts) < 2 {
-		c.Respond("Invalid data")
-		return nil
+	if c.Session == nil || len(c.Session.Args) < 1 {
+		return c.Respond("This button has expired.")
 	}
-	name := parts[1]
+	name := c.Session.Args[0]
 
 	target, err := m.Bot.GetTargetChat(c)
 	if err != nil {
@@ -198,7 +159,7 @@ func (m *Module) onGetNotePM(c *bot.Context) error {
 		return nil
 	}
 
-	err = m.deliverNote(c.Sender().ID, note)
+	err = m.deliverNote(c.Sender().ID, note, template.Data{Bot: m.Bot, User: c.Sender(), Chat: target})
 	if err != nil {
 		c.Respond("Failed to send note. Start me in PM first?")
 		return nil
@@ -207,47 +168,73 @@ func (m *Module) onGetNotePM(c *bot.Context) error {
 	return nil
 }
 
-func (m *Module) deliverNote(chatID int64, note *store.Note) error {
-	req := map[string]any{
-		"chat_id": chatID,
-	}
-
-	switch note.Type {
-	case "photo":
-		req["photo"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendPhoto", req)
-	case "video":
-		req["video"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendVideo", req)
-	case "videonote":
-		req["video_note"] = note.FileID
-		return m.Bot.Raw("sendVideoNote", req)
-	case "document":
-		req["document"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendDocument", req)
-	case "sticker":
-		req["sticker"] = note.FileID
-		return m.Bot.Raw("sendSticker", req)
-	case "voice":
-		req["voice"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendVoice", req)
-	case "audio":
-		req["audio"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendAudio", req)
-	case "animation":
-		req["animation"] = note.FileID
-		req["caption"] = note.Content
-		return m.Bot.Raw("sendAnimation", req)
-	default:
-		req["text"] = note.Content
-		req["parse_mode"] = "Markdown"
-		return m.Bot.Raw("sendMessage", req)
+func (m *Module) onStartNote(c *bot.Context) error {
+	if len(c.Args) < 2 {
+		return c.Send("Invalid note link.")
+	}
+	chatID, err := strconv.ParseInt(c.Args[0], 10, 64)
+	if err != nil {
+		return c.Send("Invalid note link.")
+	}
+
+	note, err := m.Store.GetNote(chatID, c.Args[1])
+	if err != nil || note == nil {
+		return c.Send("Note not found.")
+	}
+	chat := &bot.Chat{ID: chatID, Type: "supergroup"}
+	if group, err := m.Store.GetGroup(chatID); err == nil && group != nil {
+		chat.Title = group.Title
+	}
+	return m.deliverNote(c.Chat().ID, note, template.Data{Bot: m.Bot, User: c.Sender(), Chat: chat})
+}
+
+func (m *Module) onNoteButton(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 2 {
+		return c.Respond("This button has expired.")
+	}
+	chatID, err := strconv.ParseInt(c.Session.Args[0], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data")
+	}
+
+	note, err := m.Store.GetNote(chatID, c.Session.Args[1])
+	if err == nil && note == nil {
+		note, err = m.noteByRef(chatID, c.Session.Args[1])
+	}
+	if err != nil || note == nil {
+		return c.Respond("Note not found.")
+	}
+
+	c.Respond()
+	if c.Chat().ID == chatID {
+		return m.sendNoteResponse(c, note)
+	}
+	chat := &bot.Chat{ID: chatID, Type: "supergroup"}
+	if group, err := m.Store.GetGroup(chatID); err == nil && group != nil {
+		chat.Title = group.Title
+	}
+	return m.deliverNote(c.Chat().ID, note, template.Data{Bot: m.Bot, User: c.Sender(), Chat: chat})
+}
+
+func (m *Module) noteByRef(chatID int64, ref string) (*store.Note, error) {
+	notes, err := m.Store.GetNotes(chatID)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		if template.NoteRef(n.Name) == ref {
+			return m.Store.GetNote(chatID, n.Name)
+		}
+	}
+	return nil, nil
+}
+
+func (m *Module) deliverNote(chatID int64, note *store.Note, data template.Data) error {
+	fallback := ""
+	if note.Type == "text" {
+		fallback = template.Markdown
 	}
+	return template.RenderStored(note.Content, note.Entities, fallback, data).Send(m.Bot, chatID, note.Type, note.FileID)
 }
 
 func (m *Module) handleClear(c *bot.Context) error {
@@ -270,7 +257,7 @@ func (m *Module) handleClear(c *bot.Context) error {
 	if err != nil {
 		return c.Send("Failed to minimize note.")
 	}
-	m.Logger.Log(target.ID, "other", "Note deleted: "+name)
+	m.Logger.Emit(logging.Event{Type: "note_deleted", ChatID: target.ID, Category: "other", Actor: c.Sender(), Text: name})
 	return c.Send("Note `"+name+"` cleared.", "Markdown")
 }
 
@@ -308,7 +295,7 @@ func (m *Module) handleClearAll(c *bot.Context) error {
 	if err != nil {
 		return c.Send("Failed to clear notes.")
 	}
-	m.Logger.Log(target.ID, "other", "All notes cleared by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "notes_cleared", ChatID: target.ID, Category: "other", Actor: c.Sender()})
 	return c.Send("All notes cleared.")
 }
 
@@ -0,0 +1,198 @@
+package purge
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+var purgeTypes = map[string]func(store.IndexedMessage) bool{
+	"stickers": func(msg store.IndexedMessage) bool {
+		return msg.Type == "sticker"
+	},
+	"media": func(msg store.IndexedMessage) bool {
+		switch msg.Type {
+		case "photo", "video", "videonote", "animation", "voice", "audio", "document":
+			return true
+		}
+		return false
+	},
+	"links": func(msg store.IndexedMessage) bool {
+		return msg.Links
+	},
+}
+
+func hasLinks(msg *bot.Message) bool {
+	_, entities := msg.Content()
+	for _, e := range entities {
+		if e.Type == "url" || e.Type == "text_link" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Module) indexMessages(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(c *bot.Context) error {
+		if c.Message == nil || c.IsEdited || c.Message.Chat == nil {
+			return next(c)
+		}
+		if t := c.Chat().Type; t != "group" && t != "supergroup" {
+			return next(c)
+		}
+
+		msg := store.IndexedMessage{
+			ID:       c.Message.ID,
+			Date:     c.Message.Date,
+			ThreadID: c.Message.ThreadID,
+			Links:    hasLinks(c.Message),
+		}
+		if c.Message.From != nil {
+			msg.UserID = c.Message.From.ID
+		}
+		msg.Type, _ = c.Message.Media()
+		if c.Message.ServiceType() != "" {
+			msg.Type = "service"
+		}
+		m.Store.IndexMessage(c.Chat().ID, msg)
+
+		return next(c)
+	}
+}
+
+func (m *Module) indexed(chatID int64, match func(store.IndexedMessage) bool) []int64 {
+	msgs, err := m.Store.GetIndexedMessages(chatID)
+	if err != nil {
+		return nil
+	}
+	var ids []int64
+	for _, msg := range msgs {
+		if match(msg) {
+			ids = append(ids, msg.ID)
+		}
+	}
+	return ids
+}
+
+func (m *Module) indexedBetween(chatID, startID, endID int64, limit int) []int64 {
+	ids := m.indexed(chatID, func(msg store.IndexedMessage) bool { return msg.ID > startID && msg.ID < endID })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func (m *Module) purgeIndexed(c *bot.Context, targetChat *bot.Chat, ids []int64, what string) error {
+	if len(ids) == 0 {
+		return c.Send("No recent " + what + " found to purge.")
+	}
+	if c.Chat().ID == targetChat.ID && !slices.Contains(ids, c.Message.ID) {
+		ids = append(ids, c.Message.ID)
+	}
+	m.Bot.DeleteMessages(targetChat.ID, ids)
+	m.Store.UnindexMessages(targetChat.ID, ids)
+
+	m.Logger.Emit(logging.Event{Type: "purge", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: "Purged " + strconv.Itoa(len(ids)) + " " + what})
+	return c.Send("Purged " + strconv.Itoa(len(ids)) + " " + what + ".")
+}
+
+func (m *Module) purgeSince(c *bot.Context, targetChat *bot.Chat, d time.Duration) error {
+	since := time.Now().Add(-d).Unix()
+	ids := m.indexed(targetChat.ID, func(msg store.IndexedMessage) bool { return msg.Date >= since })
+	return m.purgeIndexed(c, targetChat, ids, "messages from the last "+d.String())
+}
+
+func (m *Module) handlePurgeUser(c *bot.Context) error {
+	targetChat, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, targetChat, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, targetChat, "can_delete_messages") {
+		return nil
+	}
+
+	args := c.Args
+	var userID int64
+	if c.Message.ReplyTo != nil && c.Message.ReplyTo.From != nil {
+		userID = c.Message.ReplyTo.From.ID
+	} else if len(args) > 0 {
+		userID, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return c.Send("Invalid user ID.")
+		}
+		args = args[1:]
+	} else {
+		return c.Send("Usage: /purgeuser <user_id> [N] or reply to a user with /purgeuser [N]")
+	}
+
+	ids := m.indexed(targetChat.ID, func(msg store.IndexedMessage) bool { return msg.UserID == userID })
+	if len(args) > 0 {
+		limit, err := strconv.Atoi(args[0])
+		if err != nil || limit <= 0 {
+			return c.Send("Invalid message count.")
+		}
+		if len(ids) > limit {
+			ids = ids[len(ids)-limit:]
+		}
+	}
+	return m.purgeIndexed(c, targetChat, ids, "messages from "+strconv.FormatInt(userID, 10))
+}
+
+func (m *Module) handlePurgeType(c *bot.Context) error {
+	targetChat, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, targetChat, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, targetChat, "can_delete_messages") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		return c.Send("Usage: /purgetype <stickers|media|links>")
+	}
+	kind := c.Args[0]
+	match, ok := purgeTypes[kind]
+	if !ok {
+		return c.Send("Unknown type. Use stickers, media or links.")
+	}
+	return m.purgeIndexed(c, targetChat, m.indexed(targetChat.ID, match), kind)
+}
+
+func (m *Module) handlePurgeTopic(c *bot.Context) error {
+	targetChat, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, targetChat, c.Sender(), "can_delete_messages") {
+		return nil
+	}
+	if !m.Bot.CheckBotAdmin(c, targetChat, "can_delete_messages") {
+		return nil
+	}
+
+	threadID := c.Message.ThreadID
+	if len(c.Args) > 0 {
+		threadID, err = strconv.ParseInt(c.Args[0], 10, 64)
+		if err != nil || threadID <= 0 {
+			return c.Send("Invalid topic ID.")
+		}
+	}
+	if threadID == 0 {
+		return c.Send("Run this inside a topic, or pass a topic ID: /purgetopic <topic_id>")
+	}
+
+	ids := m.indexed(targetChat.ID, func(msg store.IndexedMessage) bool {
+		return msg.ThreadID == threadID && msg.ID != threadID
+	})
+	return m.purgeIndexed(c, targetChat, ids, "messages in topic "+strconv.FormatInt(threadID, 10))
+}
@@ -28,27 +28,11 @@ func (m *Module) Register() {
 	m.Bot.Handle("/del", m.handleDel)
 	m.Bot.Handle("/purgefrom", m.handlePurgeFrom)
 	m.Bot.Handle("/purgeto", m.handlePurgeTo)
-}
-
-func (m *Module) deleteMessages(chatID int64, messageIDs []int) {
-	if len(messageIDs) == 0 {
-		return
-	}
+	m.Bot.Handle("/purgeuser", m.handlePurgeUser)
+	m.Bot.Handle("/purgetype", m.handlePurgeType)
+	m.Bot.Handle("/purgetopic", m.handlePurgeTopic)
 
-	batchSize := 100
-	for i := 0; i < len(messageIDs); i += batchSize {
-		end := i + batchSize
-		if end > len(messageIDs) {
-			end = len(messageIDs)
-		}
-		batch := messageIDs[i:end]
-
-		req := map[string]any{
-			"chat_id":     chatID,
-			"message_ids": batch,
-		}
-		m.Bot.Raw("deleteMessages", req)
-	}
+	m.Bot.Use(m.indexMessages)
 }
 
 func (m *Module) handlePurge(c *bot.Context) error {
@@ -64,10 +48,16 @@ func (m *Module) handlePurge(c *bot.Context) error {
 		return nil
 	}
 
+	args := c.Args
+	if len(args) > 0 {
+		if d, err := time.ParseDuration(args[0]); err == nil && d > 0 {
+			return m.purgeSince(c, targetChat, d)
+		}
+	}
+
 	if c.Message.ReplyTo == nil {
-		return c.Send("Reply to a message to purge from.")
+		return c.Send("Reply to a message to purge from, or use /purge <duration> (e.g. /purge 1h).")
 	}
-	args := c.Args
 	limit := 0
 	if len(args) > 0 {
 		if l, err := strconv.Atoi(args[0]); err == nil {
@@ -75,14 +65,12 @@ func (m *Module) handlePurge(c *bot.Context) error {
 		}
 	}
 
-	startID := int(c.Message.ReplyTo.ID)
-	endID := int(c.Message.ID)
+	startID := c.Message.ReplyTo.ID
+	endID := c.Message.ID
 
-	var toDelete []int
+	var toDelete []int64
 	if limit > 0 {
-		for i := 1; i <= limit; i++ {
-			toDelete = append(toDelete, startID+i)
-		}
+		toDelete = m.indexedBetween(targetChat.ID, startID, endID, limit)
 	} else {
 		for i := startID; i < endID; i++ {
 			toDelete = append(toDelete, i)
@@ -90,9 +78,10 @@ func (m *Module) handlePurge(c *bot.Context) error {
 	}
 	toDelete = append(toDelete, endID)
 
-	m.deleteMessages(targetChat.ID, toDelete)
+	m.Bot.DeleteMessages(targetChat.ID, toDelete)
+	m.Store.UnindexMessages(targetChat.ID, toDelete)
 
-	m.Logger.Log(targetChat.ID, "admin", "Purged "+strconv.Itoa(len(toDelete))+" messages by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "purge", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: "Purged " + strconv.Itoa(len(toDelete)) + " messages"})
 
 	if c.Message.Text != "" && (c.Message.Text == "/spurge" || len(c.Message.Text) > 7 && c.Message.Text[:7] == "/spurge") {
 		return nil
@@ -118,9 +107,9 @@ func (m *Module) handleDel(c *bot.Context) error {
 	if c.Message.ReplyTo == nil {
 		return nil
 	}
-	m.deleteMessages(targetChat.ID, []int{int(c.Message.ReplyTo.ID)})
+	m.Bot.DeleteMessages(targetChat.ID, []int64{c.Message.ReplyTo.ID})
 	c.Delete()
-	m.Logger.Log(targetChat.ID, "admin", "Deleted message ID "+strconv.FormatInt(c.Message.ReplyTo.ID, 10)+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "message_deleted", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: "Message ID " + strconv.FormatInt(c.Message.ReplyTo.ID, 10)})
 	return nil
 }
 
@@ -172,22 +161,23 @@ func (m *Module) handlePurgeTo(c *bot.Context) error {
 		}
 		return c.Send("Failed to get purge start point.")
 	}
-	startID, _ := strconv.Atoi(res)
-	endID := int(c.Message.ReplyTo.ID)
+	startID, _ := strconv.ParseInt(res, 10, 64)
+	endID := c.Message.ReplyTo.ID
 	if startID > endID {
 		startID, endID = endID, startID
 	}
 
-	var toDelete []int
+	var toDelete []int64
 	for i := startID; i <= endID; i++ {
 		toDelete = append(toDelete, i)
 	}
-	toDelete = append(toDelete, int(c.Message.ID))
+	toDelete = append(toDelete, c.Message.ID)
 
-	m.deleteMessages(targetChat.ID, toDelete)
+	m.Bot.DeleteMessages(targetChat.ID, toDelete)
+	m.Store.UnindexMessages(targetChat.ID, toDelete)
 
 	m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
-	m.Logger.Log(targetChat.ID, "admin", "Range purge executed by "+c.Sender().FirstName+". Deleted "+strconv.Itoa(len(toDelete))+" messages.")
+	m.Logger.Emit(logging.Event{Type: "purge", ChatID: targetChat.ID, Category: "admin", Actor: c.Sender(), Text: "Range purge: deleted " + strconv.Itoa(len(toDelete)) + " messages"})
 	c.Send("Range purge complete.")
 	return nil
 }
@@ -0,0 +1,239 @@
+package relay
+
+import (
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+	"lappbot/internal/template"
+)
+
+const basePath = "/relay"
+
+type Module struct {
+	Bot    *bot.Bot
+	Store  *store.Store
+	Logger *logging.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l}
+}
+
+const usage = `Usage:
+/relay - List relays
+/relay new [name] - Create a relay
+/relay template <id> <text> - Set the message template (HTML)
+/relay template <id> - Reset to the raw JSON dump
+/relay topic <id> <topic id|off> - Post into a forum topic
+/relay rotate <id> - Generate a new secret
+/relay log <id> - Show recent deliveries
+/relay remove <id> - Remove a relay
+
+Templates fill {$.field} and {$.nested.field} from the incoming JSON (array items by index, e.g. {$.commits.0.message}). Buttons work as in notes, e.g. [Open](buttonurl:{$.url}). Other placeholders such as {chatname} or {count} keep their usual meaning.`
+
+func (m *Module) Register() {
+	if !m.Bot.Cfg.RelayEnabled {
+		return
+	}
+	m.Bot.Handle("/relay", m.handleRelay)
+	m.Bot.HandleHTTP(basePath, m.serve)
+}
+
+func (m *Module) scope(c *bot.Context) (int64, bool) {
+	if c.Chat().Type != "private" {
+		c.Send("Relays can only be managed in PM. Connect to the group with /connect first.")
+		return 0, false
+	}
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		c.Send("Error resolving chat.")
+		return 0, false
+	}
+	if target.Type == "private" {
+		c.Send("Connect to a group with /connect first.")
+		return 0, false
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return 0, false
+	}
+	return target.ID, true
+}
+
+func (m *Module) endpoint(id string) string {
+	return strings.TrimSuffix(m.Bot.Cfg.WebhookURL, "/") + basePath + "/" + id
+}
+
+func (m *Module) handleRelay(c *bot.Context) error {
+	groupID, ok := m.scope(c)
+	if !ok {
+		return nil
+	}
+
+	sub := "list"
+	var args []string
+	if len(c.Args) > 0 {
+		sub = strings.ToLower(c.Args[0])
+		args = c.Args[1:]
+	}
+
+	switch sub {
+	case "list":
+		return m.list(c, groupID)
+	case "new", "add":
+		return m.create(c, groupID, args)
+	case "template":
+		return m.setTemplate(c, groupID, args)
+	case "topic":
+		return m.setTopic(c, groupID, args)
+	case "rotate":
+		return m.rotate(c, groupID, args)
+	case "log":
+		return m.log(c, groupID, args)
+	case "remove", "rm", "delete":
+		return m.remove(c, groupID, args)
+	}
+	return c.Send(usage)
+}
+
+func (m *Module) list(c *bot.Context, groupID int64) error {
+	relays, err := m.Store.GetRelays(groupID)
+	if err != nil {
+		return c.Send("Failed to fetch relays: " + err.Error())
+	}
+	if len(relays) == 0 {
+		return c.Send("No relays for this chat.\n\n" + usage)
+	}
+
+	msg := "Relays:\n"
+	for _, r := range relays {
+		msg += "• " + r.ID + " - " + r.Name
+		if r.ThreadID != 0 {
+			msg += " (topic " + strconv.FormatInt(r.ThreadID, 10) + ")"
+		}
+		if r.Template == "" {
+			msg += " [raw JSON]"
+		}
+		msg += "\n"
+	}
+	return c.Send(msg)
+}
+
+func (m *Module) create(c *bot.Context, groupID int64, args []string) error {
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		name = "default"
+	}
+	if len(name) > 64 {
+		return c.Send("Relay name is too long.")
+	}
+
+	secret, r, err := m.Store.CreateRelay(groupID, name, c.Sender().ID)
+	if err != nil {
+		return c.Send("Failed to create relay: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "relay_added", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "Relay \"" + name + "\""})
+	return c.Send("Relay created.\nID: <code>"+r.ID+"</code>\nEndpoint: <code>POST "+template.Escape(m.endpoint(r.ID), template.HTML)+"</code>\nSecret: <code>"+secret+"</code>\n\nSend the secret in the <code>X-Relay-Secret</code> header (or as <code>?secret=</code> if the sender cannot set headers). This secret will not be shown again; use /relay rotate to replace it.", "HTML")
+}
+
+func (m *Module) setTemplate(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /relay template <id> <text>")
+	}
+	text, _ := c.Message.ContentAfter(3)
+	updated, err := m.Store.SetRelayTemplate(groupID, args[0], text)
+	if err != nil {
+		return c.Send("Failed to update relay: " + err.Error())
+	}
+	if !updated {
+		return c.Send("No relay with that ID.")
+	}
+	if text == "" {
+		return c.Send("Template cleared. Incoming payloads will be posted as raw JSON.")
+	}
+	return c.Send("Template updated.")
+}
+
+func (m *Module) setTopic(c *bot.Context, groupID int64, args []string) error {
+	if len(args) < 2 {
+		return c.Send("Usage: /relay topic <id> <topic id|off>")
+	}
+	var threadID int64
+	if strings.ToLower(args[1]) != "off" {
+		var err error
+		threadID, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || threadID <= 0 {
+			return c.Send("Invalid topic ID.")
+		}
+	}
+	updated, err := m.Store.SetRelayThread(groupID, args[0], threadID)
+	if err != nil {
+		return c.Send("Failed to update relay: " + err.Error())
+	}
+	if !updated {
+		return c.Send("No relay with that ID.")
+	}
+	if threadID == 0 {
+		return c.Send("Relay will post into the main chat.")
+	}
+	return c.Send("Relay will post into topic " + args[1] + ".")
+}
+
+func (m *Module) rotate(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /relay rotate <id>")
+	}
+	secret, updated, err := m.Store.RotateRelaySecret(groupID, args[0])
+	if err != nil {
+		return c.Send("Failed to rotate secret: " + err.Error())
+	}
+	if !updated {
+		return c.Send("No relay with that ID.")
+	}
+	m.Logger.Emit(logging.Event{Type: "relay_rotated", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "Relay " + args[0]})
+	return c.Send("New secret: <code>"+secret+"</code>\nThe old secret no longer works.", "HTML")
+}
+
+func (m *Module) log(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /relay log <id>")
+	}
+	r, err := m.Store.GetRelay(args[0])
+	if err != nil || r == nil || r.GroupID != groupID {
+		return c.Send("No relay with that ID.")
+	}
+	deliveries, err := m.Store.GetRelayDeliveries(r.ID, 20)
+	if err != nil {
+		return c.Send("Failed to fetch deliveries: " + err.Error())
+	}
+	if len(deliveries) == 0 {
+		return c.Send("No deliveries yet.")
+	}
+
+	msg := "Recent deliveries for " + r.Name + ":\n"
+	for _, d := range deliveries {
+		msg += "• " + d.Time.Format("2006-01-02 15:04:05") + " - " + d.Status
+		if d.Error != "" {
+			msg += ": " + d.Error
+		}
+		msg += "\n"
+	}
+	return c.Send(msg)
+}
+
+func (m *Module) remove(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /relay remove <id>")
+	}
+	deleted, err := m.Store.DeleteRelay(groupID, args[0])
+	if err != nil {
+		return c.Send("Failed to remove relay: " + err.Error())
+	}
+	if !deleted {
+		return c.Send("No relay with that ID.")
+	}
+	m.Logger.Emit(logging.Event{Type: "relay_removed", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "Relay " + args[0]})
+	return c.Send("Relay removed.")
+}
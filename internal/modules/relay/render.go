@@ -0,0 +1,123 @@
+package relay
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/template"
+)
+
+const (
+	maxDumpLength = 3500
+	fieldPrefix   = "$."
+)
+
+func lookup(payload any, path string) (any, bool) {
+	cur := payload
+	for _, part := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func stringify(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func fill(text string, payload any, escape func(string) string) string {
+	var sb strings.Builder
+	for {
+		i := strings.IndexByte(text, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(text[i:], '}')
+		if j < 0 {
+			break
+		}
+		j += i
+
+		path, ok := strings.CutPrefix(text[i+1:j], fieldPrefix)
+		if !ok || path == "" || strings.ContainsAny(path, " \n{") {
+			sb.WriteString(text[:i+1])
+			text = text[i+1:]
+			continue
+		}
+		sb.WriteString(text[:i])
+		if v, ok := lookup(payload, path); ok {
+			sb.WriteString(escape(stringify(v)))
+		}
+		text = text[j+1:]
+	}
+	sb.WriteString(text)
+	return sb.String()
+}
+
+func raw(s string) string {
+	return s
+}
+
+func fillURL(url string, payload any) string {
+	url = fill(url, payload, raw)
+	if rest, ok := strings.CutPrefix(url, "https://"); ok && strings.Contains(rest, "://") {
+		return rest
+	}
+	return url
+}
+
+func dump(payload any) string {
+	data, _ := json.MarshalIndent(payload, "", "  ")
+	text := string(data)
+	if len(text) > maxDumpLength {
+		text = strings.ToValidUTF8(text[:maxDumpLength], "") + "\n…"
+	}
+	return "<pre>" + html.EscapeString(text) + "</pre>"
+}
+
+func render(b *bot.Bot, chat *bot.Chat, tpl string, payload any) *template.Message {
+	if tpl == "" {
+		return &template.Message{Text: dump(payload), ParseMode: template.HTML, DisablePreview: true}
+	}
+
+	msg := template.Render(tpl, template.HTML, template.Data{Bot: b, Chat: chat})
+	msg.Text = fill(msg.Text, payload, html.EscapeString)
+	if msg.Markup != nil {
+		for _, row := range msg.Markup.InlineKeyboard {
+			for i := range row {
+				row[i].Text = fill(row[i].Text, payload, raw)
+				row[i].Url = fillURL(row[i].Url, payload)
+			}
+		}
+	}
+	return msg
+}
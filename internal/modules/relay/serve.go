@@ -0,0 +1,131 @@
+package relay
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/valyala/fasthttp"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/store"
+)
+
+const (
+	maxBodySize = 64 << 10
+	rateLimit   = 20
+	rateWindow  = time.Minute
+)
+
+func (m *Module) record(id, status string, err error, messageID int64) {
+	d := store.RelayDelivery{Status: status, MessageID: messageID, Time: time.Now()}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if err := m.Store.AddRelayDelivery(id, d); err != nil {
+		log.Error().Err(err).Str("relay", id).Msg("Failed to record relay delivery")
+	}
+}
+
+func parseBody(ctx *fasthttp.RequestCtx) (any, error) {
+	if strings.HasPrefix(string(ctx.Request.Header.ContentType()), "application/x-www-form-urlencoded") {
+		form := make(map[string]any)
+		ctx.PostArgs().VisitAll(func(k, v []byte) {
+			form[string(k)] = string(v)
+		})
+		return form, nil
+	}
+
+	var payload any
+	if err := json.Unmarshal(ctx.PostBody(), &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (m *Module) serve(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(string(ctx.Path()), basePath), "/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(ctx, fasthttp.StatusNotFound, "not found")
+		return
+	}
+	r, err := m.Store.GetRelay(id)
+	if err != nil {
+		writeError(ctx, fasthttp.StatusInternalServerError, err.Error())
+		return
+	}
+	if r == nil {
+		writeError(ctx, fasthttp.StatusNotFound, "not found")
+		return
+	}
+
+	secret := string(ctx.Request.Header.Peek("X-Relay-Secret"))
+	if secret == "" {
+		secret = string(ctx.QueryArgs().Peek("secret"))
+	}
+	if !r.CheckSecret(secret) {
+		m.record(r.ID, "rejected", errors.New("invalid secret"), 0)
+		writeError(ctx, fasthttp.StatusUnauthorized, "invalid secret")
+		return
+	}
+
+	if !m.Store.AllowRelay(r.ID, rateLimit, rateWindow) {
+		m.record(r.ID, "rate limited", nil, 0)
+		writeError(ctx, fasthttp.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	if len(ctx.PostBody()) > maxBodySize {
+		m.record(r.ID, "rejected", errors.New("payload too large"), 0)
+		writeError(ctx, fasthttp.StatusRequestEntityTooLarge, "payload too large")
+		return
+	}
+	payload, err := parseBody(ctx)
+	if err != nil {
+		m.record(r.ID, "rejected", err, 0)
+		writeError(ctx, fasthttp.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	chat := &bot.Chat{ID: r.GroupID, Type: "supergroup"}
+	if g, err := m.Store.GetGroup(r.GroupID); err == nil && g != nil {
+		chat.Title = g.Title
+	}
+
+	req := map[string]any{"chat_id": r.GroupID}
+	if r.ThreadID != 0 {
+		req["message_thread_id"] = r.ThreadID
+	}
+	render(m.Bot, chat, r.Template, payload).Apply(req, "text")
+
+	msg, err := m.Bot.RawMessage("sendMessage", req)
+	if err != nil {
+		m.record(r.ID, "failed", err, 0)
+		writeError(ctx, fasthttp.StatusBadGateway, err.Error())
+		return
+	}
+	m.record(r.ID, "delivered", nil, msg.ID)
+	writeJSON(ctx, fasthttp.StatusOK, map[string]any{"ok": true, "message_id": msg.ID})
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error("internal error", fasthttp.StatusInternalServerError)
+		return
+	}
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(data)
+}
+
+func writeError(ctx *fasthttp.RequestCtx, status int, message string) {
+	writeJSON(ctx, status, map[string]string{"error": message})
+}
@@ -0,0 +1,233 @@
+package rules
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+	"lappbot/internal/template"
+)
+
+type Module struct {
+	Bot    *bot.Bot
+	Store  *store.Store
+	Logger *logging.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l}
+}
+
+const defaultButton = "Rules"
+
+func (m *Module) Register() {
+	m.Bot.Handle("/rules", m.handleRules)
+	m.Bot.Handle("/setrules", m.handleSetRules)
+	m.Bot.Handle("/resetrules", m.handleResetRules)
+	m.Bot.Handle("/privaterules", m.handlePrivateRules)
+	m.Bot.Handle("/setrulesbutton", m.handleSetRulesButton)
+	m.Bot.HandleStart("rules", m.onStartRules)
+	m.Bot.RegisterConversation(&bot.Conversation{
+		Name:  "rules",
+		Title: "Rules",
+		Perm:  "can_change_info",
+		Steps: []bot.Step{
+			{
+				Key:      "rules",
+				Prompt:   "Send me the rules of this chat. Formatting is preserved.",
+				Optional: true,
+				Validate: validateRules,
+			},
+			{
+				Key:     "private",
+				Prompt:  "Should /rules send a button to read the rules in PM?",
+				Options: []string{"on", "off"},
+			},
+		},
+		Done: m.finishSetup,
+	})
+}
+
+func validateRules(c *bot.Context, _ string, values map[string]string) error {
+	if c.Message == nil {
+		return errors.New("Please send the rules as a message.")
+	}
+	content, entities := c.Message.Content()
+	if content == "" {
+		return errors.New("The rules cannot be empty.")
+	}
+	values["rules"] = content
+	values["entities"] = string(template.EncodeEntities(entities))
+	return nil
+}
+
+func (m *Module) finishSetup(c *bot.Context, target int64, values map[string]string) error {
+	if _, ok := values["rules"]; ok {
+		if err := m.Store.SetRules(target, values["rules"], []byte(values["entities"])); err != nil {
+			return c.Send("Error updating rules: " + err.Error())
+		}
+	}
+	if err := m.Store.SetRulesPrivate(target, values["private"] == "on"); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "rules_updated", ChatID: target, Category: "settings", Actor: c.Sender(), Text: "Via setup"})
+	return c.Send("Rules setup complete.")
+}
+
+func (m *Module) deliverRules(chatID int64, group *store.Group, target *bot.Chat, user *bot.User) error {
+	msg := template.RenderStored(group.Rules, group.RulesEntities, template.Markdown, template.Data{Bot: m.Bot, User: user, Chat: target})
+	return msg.Send(m.Bot, chatID, "text", "")
+}
+
+func (m *Module) handleRules(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	group, err := m.Store.GetGroup(target.ID)
+	if err != nil || group == nil {
+		return c.Send("Error fetching group info.")
+	}
+	if group.Rules == "" {
+		return c.Send("No rules have been set for this chat.")
+	}
+
+	if group.RulesPrivate && c.Chat().Type != "private" {
+		url := m.Bot.StartLink("rules", target.ID)
+		if url != "" {
+			markup := &bot.ReplyMarkup{
+				InlineKeyboard: [][]bot.InlineKeyboardButton{{{Text: group.RulesButton, Url: url}}},
+			}
+			return c.Send("Click the button below to read the rules of this chat.", markup)
+		}
+	}
+
+	return m.deliverRules(c.Chat().ID, group, target, c.Sender())
+}
+
+func (m *Module) onStartRules(c *bot.Context) error {
+	chatID, err := strconv.ParseInt(c.Args[0], 10, 64)
+	if err != nil {
+		return c.Send("Invalid rules link.")
+	}
+	group, err := m.Store.GetGroup(chatID)
+	if err != nil || group == nil {
+		return c.Send("I don't know that chat.")
+	}
+	if group.Rules == "" {
+		return c.Send("No rules have been set for " + group.Title + ".")
+	}
+
+	if err := c.Send("Rules for " + group.Title + ":"); err != nil {
+		return err
+	}
+	return m.deliverRules(c.Chat().ID, group, &bot.Chat{ID: group.TelegramID, Title: group.Title, Type: "supergroup"}, c.Sender())
+}
+
+func (m *Module) handleSetRules(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	content, entities := c.Message.ContentAfter(1)
+	if content == "" && c.Message.ReplyTo != nil {
+		content, entities = c.Message.ReplyTo.Content()
+	}
+	if content == "" {
+		return c.Send("Please provide the rules or reply to a message containing them.")
+	}
+
+	if err := m.Store.SetRules(target.ID, content, template.EncodeEntities(entities)); err != nil {
+		return c.Send("Error updating rules: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "rules_updated", ChatID: target.ID, Category: "settings", Actor: c.Sender()})
+	return c.Send("Rules updated.")
+}
+
+func (m *Module) handleResetRules(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	if err := m.Store.SetRules(target.ID, "", nil); err != nil {
+		return c.Send("Error resetting rules: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "rules_reset", ChatID: target.ID, Category: "settings", Actor: c.Sender()})
+	return c.Send("Rules have been reset.")
+}
+
+func (m *Module) handlePrivateRules(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	if len(c.Args) == 0 {
+		group, err := m.Store.GetGroup(target.ID)
+		if err != nil || group == nil {
+			return c.Send("Error fetching group info.")
+		}
+		status := "off"
+		if group.RulesPrivate {
+			status = "on"
+		}
+		return c.Send("Private rules are " + status + ".\nUsage: /privaterules <on|off>")
+	}
+
+	var enabled bool
+	switch strings.ToLower(c.Args[0]) {
+	case "on", "yes":
+		enabled = true
+	case "off", "no":
+		enabled = false
+	default:
+		return c.Send("Usage: /privaterules <on|off>")
+	}
+
+	if err := m.Store.SetRulesPrivate(target.ID, enabled); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target.ID, Category: "settings", Actor: c.Sender(), Text: "Private rules set to " + c.Args[0]})
+	if enabled {
+		return c.Send("/rules will now send a button to read the rules in PM.")
+	}
+	return c.Send("/rules will now send the rules in the chat.")
+}
+
+func (m *Module) handleSetRulesButton(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return nil
+	}
+
+	text := strings.TrimSpace(strings.Join(c.Args, " "))
+	if text == "" {
+		text = defaultButton
+	}
+	if len(text) > 64 {
+		return c.Send("Button text is too long.")
+	}
+
+	if err := m.Store.SetRulesButton(target.ID, text); err != nil {
+		return c.Send("Error updating setting: " + err.Error())
+	}
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target.ID, Category: "settings", Actor: c.Sender(), Text: "Rules button set to \"" + text + "\""})
+	return c.Send("Rules button text set to: " + text)
+}
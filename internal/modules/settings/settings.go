@@ -0,0 +1,393 @@
+package settings
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+type Module struct {
+	Bot    *bot.Bot
+	Store  *store.Store
+	Logger *logging.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l}
+}
+
+const endpoint = "settings"
+
+type option struct {
+	Key     string
+	Label   string
+	Value   func(g *store.Group) string
+	Choices []string
+	Min     int
+	Max     int
+	Set     func(s *store.Store, id int64, v string) error
+	Wizard  string
+}
+
+type page struct {
+	Title   string
+	Perm    string
+	Options []option
+}
+
+var onOff = []string{"on", "off"}
+
+func boolValue(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func firstWord(s string) string {
+	word, _, _ := strings.Cut(s, " ")
+	return word
+}
+
+var pages = []page{
+	{
+		Title: "Greetings",
+		Perm:  "can_change_info",
+		Options: []option{
+			{
+				Key: "welcome", Label: "Welcome", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.GreetingEnabled) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetGreetingStatus(id, v == "on") },
+			},
+			{
+				Key: "goodbye", Label: "Goodbye", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.GoodbyeEnabled) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetGoodbyeStatus(id, v == "on") },
+			},
+			{
+				Key: "cleanwelcome", Label: "Clean welcome", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.CleanWelcome) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetCleanWelcome(id, v == "on") },
+			},
+			{
+				Key: "welcomemute", Label: "Welcome mute", Choices: []string{"off", "soft", "strong"},
+				Value: func(g *store.Group) string { return g.WelcomeMute },
+				Set: func(s *store.Store, id int64, v string) error {
+					g, err := s.GetGroup(id)
+					if err != nil {
+						return err
+					}
+					return s.SetWelcomeMute(id, v, g.WelcomeMuteTime)
+				},
+			},
+			{Key: "welcomemsg", Label: "Edit welcome", Wizard: "welcome"},
+		},
+	},
+	{
+		Title: "Anti-Spam",
+		Perm:  "can_restrict_members",
+		Options: []option{
+			{
+				Key: "captcha", Label: "CAPTCHA", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.CaptchaEnabled) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.UpdateGroupCaptcha(id, v == "on") },
+			},
+			{
+				Key: "joinrequests", Label: "Join requests", Choices: []string{"off", "auto", "captcha", "manual"},
+				Value: func(g *store.Group) string { return g.JoinRequestMode },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetJoinRequestMode(id, v) },
+			},
+			{
+				Key: "flood", Label: "Flood limit", Min: 0, Max: 50,
+				Value: func(g *store.Group) string { return strconv.Itoa(g.AntifloodConsecutiveLimit) },
+				Set: func(s *store.Store, id int64, v string) error {
+					n, _ := strconv.Atoi(v)
+					return s.SetAntifloodConsecutiveLimit(id, n)
+				},
+			},
+			{
+				Key: "floodmode", Label: "Flood action", Choices: []string{"mute", "kick", "ban"},
+				Value: func(g *store.Group) string { return firstWord(g.AntifloodAction) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetAntifloodAction(id, v) },
+			},
+			{
+				Key: "clearflood", Label: "Delete flood", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.AntifloodDelete) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetAntifloodDelete(id, v == "on") },
+			},
+		},
+	},
+	{
+		Title: "Warnings",
+		Perm:  "can_restrict_members",
+		Options: []option{
+			{
+				Key: "warnlimit", Label: "Warn limit", Min: 1, Max: 20,
+				Value: func(g *store.Group) string { return strconv.Itoa(g.WarnLimit) },
+				Set: func(s *store.Store, id int64, v string) error {
+					n, _ := strconv.Atoi(v)
+					return s.SetWarnLimit(id, n)
+				},
+			},
+			{
+				Key: "warnmode", Label: "Warn action", Choices: []string{"kick", "ban", "mute"},
+				Value: func(g *store.Group) string { return firstWord(g.WarnAction) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetWarnAction(id, v) },
+			},
+			{Key: "warnsetup", Label: "Warn setup", Wizard: "warns"},
+		},
+	},
+	{
+		Title: "Notes & Rules",
+		Perm:  "can_change_info",
+		Options: []option{
+			{
+				Key: "privatenotes", Label: "Private notes", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.NotesPrivate) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetNotesPrivate(id, v == "on") },
+			},
+			{
+				Key: "privaterules", Label: "Private rules", Choices: onOff,
+				Value: func(g *store.Group) string { return boolValue(g.RulesPrivate) },
+				Set:   func(s *store.Store, id int64, v string) error { return s.SetRulesPrivate(id, v == "on") },
+			},
+			{Key: "rules", Label: "Edit rules", Wizard: "rules"},
+		},
+	},
+}
+
+var ErrUnknownSetting = errors.New("unknown setting")
+
+func lookup(key string) (*page, *option) {
+	for i := range pages {
+		if opt := findOption(pages[i], key); opt != nil && opt.Wizard == "" {
+			return &pages[i], opt
+		}
+	}
+	return nil, nil
+}
+
+type Setting struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label"`
+	Value   string   `json:"value"`
+	Options []string `json:"options,omitempty"`
+}
+
+func Values(g *store.Group) []Setting {
+	var values []Setting
+	for _, p := range pages {
+		for _, opt := range p.Options {
+			if opt.Wizard == "" {
+				values = append(values, Setting{Key: opt.Key, Label: opt.Label, Value: opt.Value(g), Options: opt.Choices})
+			}
+		}
+	}
+	return values
+}
+
+func Permission(key string) string {
+	p, _ := lookup(key)
+	if p == nil {
+		return ""
+	}
+	return p.Perm
+}
+
+func Apply(s *store.Store, id int64, key, value string) error {
+	_, opt := lookup(key)
+	if opt == nil {
+		return ErrUnknownSetting
+	}
+	if opt.Choices != nil {
+		if !slices.Contains(opt.Choices, value) {
+			return errors.New("value must be one of: " + strings.Join(opt.Choices, ", "))
+		}
+	} else {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < opt.Min || n > opt.Max {
+			return errors.New("value must be a number between " + strconv.Itoa(opt.Min) + " and " + strconv.Itoa(opt.Max))
+		}
+	}
+	return opt.Set(s, id, value)
+}
+
+func (m *Module) Register() {
+	m.Bot.Handle("/settings", m.handleSettings)
+	m.Bot.Handle(endpoint, m.onSettingsButton)
+}
+
+func (m *Module) handleSettings(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if target.Type == "private" {
+		return c.Send("Use /settings in a group, or connect to one with /connect first.")
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender()) {
+		return nil
+	}
+
+	group, err := m.Store.GetGroup(target.ID)
+	if err != nil || group == nil {
+		return c.Send("Error fetching group info.")
+	}
+	text, markup := m.render(c, group, 0)
+	return c.Send(text, markup)
+}
+
+func (m *Module) button(c *bot.Context, text string, target int64, args ...string) bot.InlineKeyboardButton {
+	return m.Bot.CallbackButton(text, bot.CallbackSession{
+		Endpoint: endpoint,
+		Args:     append([]string{strconv.FormatInt(target, 10)}, args...),
+		ChatID:   c.Chat().ID,
+		UserID:   c.Sender().ID,
+	})
+}
+
+func (m *Module) render(c *bot.Context, group *store.Group, n int) (string, *bot.ReplyMarkup) {
+	p := pages[n]
+	pageStr := strconv.Itoa(n)
+
+	var sb strings.Builder
+	sb.WriteString("Settings for " + group.Title + "\n")
+	sb.WriteString(p.Title + " (" + strconv.Itoa(n+1) + "/" + strconv.Itoa(len(pages)) + ")\n\n")
+
+	var rows [][]bot.InlineKeyboardButton
+	for _, opt := range p.Options {
+		switch {
+		case opt.Wizard != "":
+			rows = append(rows, []bot.InlineKeyboardButton{m.button(c, opt.Label, group.TelegramID, "wizard", pageStr, opt.Key)})
+		case opt.Choices != nil:
+			value := opt.Value(group)
+			sb.WriteString(opt.Label + ": " + value + "\n")
+			rows = append(rows, []bot.InlineKeyboardButton{m.button(c, opt.Label+": "+value, group.TelegramID, "cycle", pageStr, opt.Key)})
+		default:
+			value := opt.Value(group)
+			sb.WriteString(opt.Label + ": " + value + "\n")
+			rows = append(rows, []bot.InlineKeyboardButton{
+				m.button(c, "-", group.TelegramID, "dec", pageStr, opt.Key),
+				m.button(c, opt.Label+": "+value, group.TelegramID, "page", pageStr),
+				m.button(c, "+", group.TelegramID, "inc", pageStr, opt.Key),
+			})
+		}
+	}
+
+	var nav []bot.InlineKeyboardButton
+	if n > 0 {
+		nav = append(nav, m.button(c, "« "+pages[n-1].Title, group.TelegramID, "page", strconv.Itoa(n-1)))
+	}
+	if n < len(pages)-1 {
+		nav = append(nav, m.button(c, pages[n+1].Title+" »", group.TelegramID, "page", strconv.Itoa(n+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, []bot.InlineKeyboardButton{m.button(c, "Close", group.TelegramID, "close")})
+
+	return sb.String(), &bot.ReplyMarkup{InlineKeyboard: rows}
+}
+
+func findOption(p page, key string) *option {
+	for i := range p.Options {
+		if p.Options[i].Key == key {
+			return &p.Options[i]
+		}
+	}
+	return nil
+}
+
+func (m *Module) onSettingsButton(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 2 {
+		return c.Respond("This panel has expired. Use /settings again.")
+	}
+	args := c.Session.Args
+	target, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+	if args[1] == "close" {
+		c.Respond()
+		return c.Delete()
+	}
+	if len(args) < 3 {
+		return c.Respond("Invalid data.")
+	}
+	n, err := strconv.Atoi(args[2])
+	if err != nil || n < 0 || n >= len(pages) {
+		return c.Respond("Invalid data.")
+	}
+	p := pages[n]
+
+	if !m.Bot.IsAdmin(&bot.Chat{ID: target}, c.Sender(), p.Perm) {
+		return c.Respond("You need the " + p.Perm + " right to change these settings.")
+	}
+
+	group, err := m.Store.GetGroup(target)
+	if err != nil || group == nil {
+		return c.Respond("Error fetching group info.")
+	}
+
+	if args[1] != "page" {
+		if len(args) < 4 {
+			return c.Respond("Invalid data.")
+		}
+		opt := findOption(p, args[3])
+		if opt == nil {
+			return c.Respond("Unknown setting.")
+		}
+
+		if args[1] == "wizard" {
+			c.Respond()
+			return m.Bot.StartConversation(c, opt.Wizard, target)
+		}
+
+		value, ok := nextValue(opt, opt.Value(group), args[1])
+		if !ok {
+			return c.Respond(opt.Label + " is already at its limit.")
+		}
+		if err := opt.Set(m.Store, target, value); err != nil {
+			return c.Respond("Error updating setting: " + err.Error())
+		}
+		m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: target, Category: "settings", Actor: c.Sender(), Text: opt.Label + " set to " + value + " via /settings"})
+
+		group, err = m.Store.GetGroup(target)
+		if err != nil || group == nil {
+			return c.Respond("Error fetching group info.")
+		}
+	}
+
+	c.Respond()
+	text, markup := m.render(c, group, n)
+	return c.Edit(text, markup)
+}
+
+func nextValue(opt *option, current, action string) (string, bool) {
+	switch action {
+	case "cycle":
+		for i, choice := range opt.Choices {
+			if choice == current {
+				return opt.Choices[(i+1)%len(opt.Choices)], true
+			}
+		}
+		return opt.Choices[0], true
+	case "inc", "dec":
+		n, _ := strconv.Atoi(current)
+		if action == "inc" {
+			n++
+		} else {
+			n--
+		}
+		if n < opt.Min || n > opt.Max {
+			return "", false
+		}
+		return strconv.Itoa(n), true
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package setup
+
+import (
+	"strconv"
+	"strings"
+
+	"lappbot/internal/bot"
+)
+
+type Module struct {
+	Bot *bot.Bot
+}
+
+func New(b *bot.Bot) *Module {
+	return &Module{Bot: b}
+}
+
+func (m *Module) Register() {
+	m.Bot.Handle("/setup", m.handleSetup)
+	m.Bot.Handle("setup_start", m.onSetupButton)
+}
+
+func (m *Module) handleSetup(c *bot.Context) error {
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		return c.Send("Error resolving chat.")
+	}
+	if target.Type == "private" {
+		return c.Send("Use /setup in a group, or connect to one with /connect first.")
+	}
+
+	if len(c.Args) > 0 {
+		name := strings.ToLower(c.Args[0])
+		for _, conv := range m.Bot.Conversations() {
+			if conv.Name == name {
+				if conv.Perm != "" && !m.Bot.CheckAdmin(c, target, c.Sender(), conv.Perm) {
+					return nil
+				}
+				return m.Bot.StartConversation(c, name, target.ID)
+			}
+		}
+		return c.Send("Unknown setup: " + name)
+	}
+
+	if !m.Bot.CheckAdmin(c, target, c.Sender()) {
+		return nil
+	}
+
+	var rows [][]bot.InlineKeyboardButton
+	for _, conv := range m.Bot.Conversations() {
+		rows = append(rows, []bot.InlineKeyboardButton{m.Bot.CallbackButton(conv.Title, bot.CallbackSession{
+			Endpoint: "setup_start",
+			Args:     []string{conv.Name, strconv.FormatInt(target.ID, 10)},
+			ChatID:   c.Chat().ID,
+			UserID:   c.Sender().ID,
+		})})
+	}
+	return c.Send("What would you like to set up?", &bot.ReplyMarkup{InlineKeyboard: rows})
+}
+
+func (m *Module) onSetupButton(c *bot.Context) error {
+	if c.Session == nil || len(c.Session.Args) < 2 {
+		return c.Respond("This button has expired.")
+	}
+	targetID, err := strconv.ParseInt(c.Session.Args[1], 10, 64)
+	if err != nil {
+		return c.Respond("Invalid data.")
+	}
+
+	name := c.Session.Args[0]
+	for _, conv := range m.Bot.Conversations() {
+		if conv.Name != name {
+			continue
+		}
+		if conv.Perm != "" && !m.Bot.IsAdmin(&bot.Chat{ID: targetID}, c.Sender(), conv.Perm) {
+			return c.Respond("You don't have permission to change these settings.")
+		}
+		c.Respond()
+		return m.Bot.StartConversation(c, name, targetID)
+	}
+	return c.Respond("Unknown setup.")
+}
@@ -71,7 +71,7 @@ func (m *Module) handleSetActionTopic(c *bot.Context) error {
 		return c.Send("Error setting action topic.")
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "Action topic set to ID "+strconv.FormatInt(int64(topicID), 10)+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "settings_changed", ChatID: c.Chat().ID, Category: "settings", Actor: c.Sender(), Text: "Action topic set to ID " + strconv.FormatInt(int64(topicID), 10)})
 
 	return c.Send("Action topic set to current topic (ID: `"+strconv.FormatInt(topicID, 10)+"`).", "Markdown")
 }
@@ -98,7 +98,7 @@ func (m *Module) handleNewTopic(c *bot.Context) error {
 		return c.Send("Error creating topic: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "New topic created: "+topicName+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "topic_created", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender(), Text: topicName})
 
 	return c.Send("Topic created: " + topicName)
 }
@@ -130,7 +130,7 @@ func (m *Module) handleRenameTopic(c *bot.Context) error {
 		return c.Send("Error renaming topic: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "Topic renamed to "+topicName+" by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "topic_renamed", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender(), Text: topicName})
 
 	return c.Send("Topic renamed to: " + topicName)
 }
@@ -155,7 +155,7 @@ func (m *Module) handleCloseTopic(c *bot.Context) error {
 		return c.Send("Error closing topic: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "Topic closed by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "topic_closed", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender()})
 
 	return c.Send("Topic closed.")
 }
@@ -180,7 +180,7 @@ func (m *Module) handleReopenTopic(c *bot.Context) error {
 		return c.Send("Error reopening topic: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "Topic reopened by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "topic_reopened", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender()})
 
 	return c.Send("Topic reopened.")
 }
@@ -205,7 +205,7 @@ func (m *Module) handleDeleteTopic(c *bot.Context) error {
 		return c.Send("Error deleting topic: " + err.Error())
 	}
 
-	m.Logger.Log(c.Chat().ID, "other", "Topic deleted by "+c.Sender().FirstName)
+	m.Logger.Emit(logging.Event{Type: "topic_deleted", ChatID: c.Chat().ID, Category: "other", Actor: c.Sender()})
 
 	return nil
 }
@@ -40,11 +40,19 @@ func (m *Module) Register() {
 	m.Bot.Handle("help_cursed", m.onHelpCallback)
 	m.Bot.Handle("help_clean", m.onHelpCallback)
 	m.Bot.Handle("help_logging", m.onHelpCallback)
+	m.Bot.Handle("help_rules", m.onHelpCallback)
 	m.Bot.Handle("btn_refresh_ping", m.handlePingRefresh)
 	m.Bot.Handle("/report", m.handleReport)
 }
 
 func (m *Module) handleStart(c *bot.Context) error {
+	if len(c.Args) > 0 && c.Chat().Type == "private" {
+		if h, args, ok := m.Bot.ResolveStart(c.Args[0]); ok {
+			c.Args = args
+			return h(c)
+		}
+		return c.Send("This link is invalid or has expired.")
+	}
 	return c.Send("Hello! I am " + m.Cfg.BotName + ". Use /help to see what I can do.")
 }
 
@@ -107,19 +115,24 @@ var helpCache = map[string]struct {
 				{{Text: "Realm", CallbackData: "help_realm"}, {Text: "Anti-Spam", CallbackData: "help_antispam"}, {Text: "Purges", CallbackData: "help_purges"}},
 				{{Text: "Notes", CallbackData: "help_notes"}, {Text: "Connection", CallbackData: "help_conn"}, {Text: "Logging", CallbackData: "help_logging"}},
 				{{Text: "Topics", CallbackData: "help_topics"}, {Text: "Cursed", CallbackData: "help_cursed"}, {Text: "Clean", CallbackData: "help_clean"}},
+				{{Text: "Rules", CallbackData: "help_rules"}},
 			},
 		},
 	},
 	"logging": {
 		Text: `**Logging Commands:**
-/loggroup - View Log Group
-/setlog <group_id> - Set Log Group
+/loggroup - View Log Chat and Groups Logging Here
+/setlog - Link a Log Chat (send it there, then forward it or use the code)
 /unsetlog - Unset Log Group
 /log <category> - Enable Log Category
 /nolog <category> - Disable Log Category
 /logcategories - List Log Categories
+/logroute <category> <code> [topic_id] - Send a Category to Another Chat or Topic (code from /setlog there)
+/evidence <on|off> <source> - Copy Deleted Messages to the Log Chat
+/evidence retention <duration|off> - Auto-delete Captured Copies
 
-Categories: settings, admin, user, automated, reports, other`,
+Categories: settings, admin, user, automated, reports, other
+Evidence sources: blacklist, antiflood, warn`,
 	},
 	"cursed": {
 		Text: `**Cursed Commands:**
@@ -153,15 +166,37 @@ Categories: settings, admin, user, automated, reports, other`,
 /cleancommand <type> - Add type to clean list
 /keepcommand <type> - Remove type from clean list
 /cleancommandtypes - List available types
+/autodelete <type> <time|off> - Auto-delete bot messages
+/cleanservice <type> - Delete service messages
+/keepservice <type> - Keep service messages
 
-Types: settings, admin, user, automated, reports, other, all`,
+Types: settings, admin, user, automated, reports, other, all
+Auto-delete types: welcome, goodbye, captcha, antiflood, commands
+Service types: join, leave, pin, photo, title, videochat, topic, boost, all`,
+	},
+	"rules": {
+		Text: `**Rules Commands:**
+/rules - Show the chat rules
+/setrules <text> - Set rules (or reply to a message)
+/resetrules - Remove the rules
+/privaterules <on|off> - Send rules in PM
+/setrulesbutton [text] - Set rules button text
+
+Use {rules} in a welcome message or note to add a rules button.`,
 	},
 	"conn": {
 		Text: `**Connection Commands:**
 /connect <chat> - Connect to Chat
 /disconnect - Disconnect
 /reconnect - Reconnect
-/connection - Check Connection`,
+/connection - Check Connection
+
+**Integrations (PM):**
+/newapikey [name] - Create an API key for the connected chat
+/apikeys - List API keys
+/revokeapikey <id> - Revoke an API key
+/webhooks - Manage outgoing webhooks
+/relay - Manage inbound relays that post external notifications`,
 	},
 	"mod": {
 		Text: `**Moderation Commands:**
@@ -172,6 +207,7 @@ Types: settings, admin, user, automated, reports, other, all`,
 /tmute <duration> [reason] - Timed Mute (Reply)
 /skick - Silent Kick (Reply)
 /sban - Silent Ban (Reply)
+/dban [reason] - Ban and Delete Their Recent Messages (Reply)
 /smute - Silent Mute (Reply)
 /unban - Unban (Reply)
 /unmute - Unmute (Reply)
@@ -182,10 +218,16 @@ Types: settings, admin, user, automated, reports, other, all`,
 	"purges": {
 		Text: `**Purge Commands:**
 /purge [count] - Purge messages
+/purge <duration> - Purge messages from the last duration (e.g. 1h)
 /spurge [count] - Silent purge
 /del - Delete message
 /purgefrom - Mark start
-/purgeto - Purge range`,
+/purgeto - Purge range
+/purgeuser <user> [count] - Purge a user's recent messages (or reply)
+/purgetype <stickers|media|links> - Purge recent messages by type
+/purgetopic [topic_id] - Purge recent messages in a topic
+
+Only messages from the last 48 hours that I have seen can be purged by user, type, topic or duration.`,
 	},
 	"warns": {
 		Text: `**Warning Commands:**
@@ -219,10 +261,21 @@ Types: settings, admin, user, automated, reports, other, all`,
 		Text: `**Group Settings:**
 /welcome <on|off|text> [msg] - Welcome Msg
 /goodbye <on|off|text> [msg] - Goodbye Msg
+/setwelcome [msg] - Set Welcome (or reply to media)
+/setgoodbye [msg] - Set Goodbye (or reply to media)
+/cleanwelcome <on|off> - Delete Previous Welcome
+/welcomemute <off|soft|strong> [time] - Mute Newcomers
+/setup [welcome|rules|warns] - Interactive setup
+/settings - Settings panel
+/dashboard - Open the Mini App dashboard
 /captcha <on|off> - CAPTCHA
+/joinrequests <off|auto|captcha|manual> - Join Requests
 
 **Placeholders:**
-{firstname}, {username}, {userid}`,
+{first}, {last}, {fullname}, {username}, {mention}, {id}
+{chatname}, {count}, {rules}
+{preview}, {nonotif}, {protect}
+Separate random alternatives with %%%`,
 	},
 	"filters": {
 		Text: `**Filter Commands:**
@@ -244,6 +297,8 @@ Types: settings, admin, user, automated, reports, other, all`,
 		Text: `**Realm Commands:**
 /rban [reason] - Realm Ban (Reply)
 /rmute [reason] - Realm Mute (Reply)
+/chats - List Groups
+/leave <id> - Leave Group
 (Bot Owner Only)`,
 	},
 }
@@ -315,19 +370,7 @@ func (m *Module) handleReport(c *bot.Context) error {
 		m.Bot.Raw("sendMessage", payload)
 	}
 
-	m.Logger.Log(c.Chat().ID, "reports", "Report filed by "+reporter.FirstName+"\nTriggering user: "+reportedUser.FirstName+"\nReason: "+reasonStr)
+	m.Logger.Emit(logging.Event{Type: "report", ChatID: c.Chat().ID, Category: "reports", Actor: reporter, Target: reportedUser, Reason: reasonStr, MessageID: logging.Source(c, c.Chat().ID)})
 
 	return c.Send("Report sent to admins.")
 }
-
-func ReplacePlaceholders(msg string, user *bot.User) string {
-	userIDStr := strconv.FormatInt(user.ID, 10)
-	firstNameLink := "[" + user.FirstName + "](tg://user?id=" + userIDStr + ")"
-
-	r := strings.NewReplacer(
-		"{firstname}", firstNameLink,
-		"{username}", user.Username,
-		"{userid}", userIDStr,
-	)
-	return r.Replace(msg)
-}
@@ -0,0 +1,184 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/valyala/fasthttp"
+
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+const (
+	deliveryQueue   = "webhooks"
+	deliveryTTL     = 48 * time.Hour
+	deliveryTimeout = 10 * time.Second
+	maxAttempts     = 6
+	baseBackoff     = 15 * time.Second
+)
+
+type payload struct {
+	ID    string        `json:"id"`
+	Event logging.Event `json:"event"`
+}
+
+type delivery struct {
+	WebhookID string          `json:"w"`
+	EventType string          `json:"t"`
+	Body      json.RawMessage `json:"b"`
+	Attempt   int             `json:"a"`
+}
+
+var client = &fasthttp.Client{
+	ReadTimeout:  deliveryTimeout,
+	WriteTimeout: deliveryTimeout,
+}
+
+func deliveryKey(id string) string {
+	return "whd:" + id
+}
+
+func matches(hook store.Webhook, ev logging.Event) bool {
+	return slices.Contains(hook.Events, "*") || slices.Contains(hook.Events, ev.Type) || slices.Contains(hook.Events, ev.Category)
+}
+
+func backoff(attempt int) time.Duration {
+	return baseBackoff * time.Duration(1<<(attempt-1))
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Module) onEvent(ev logging.Event) {
+	var hooks []store.Webhook
+	for _, scope := range []int64{ev.ChatID, 0} {
+		scoped, err := m.Store.GetWebhooks(scope)
+		if err != nil {
+			log.Error().Err(err).Int64("chat_id", scope).Msg("Failed to load webhooks")
+			continue
+		}
+		hooks = append(hooks, scoped...)
+	}
+
+	for _, hook := range hooks {
+		if matches(hook, ev) {
+			m.enqueue(hook.ID, ev)
+		}
+	}
+}
+
+func (m *Module) enqueue(webhookID string, ev logging.Event) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return
+	}
+	body, err := json.Marshal(payload{ID: id, Event: ev})
+	if err != nil {
+		return
+	}
+	m.schedule(id, &delivery{WebhookID: webhookID, EventType: ev.Type, Body: body}, time.Now())
+}
+
+func (m *Module) schedule(id string, d *delivery, at time.Time) {
+	data, _ := json.Marshal(d)
+	err := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Set().Key(deliveryKey(id)).Value(string(data)).Ex(deliveryTTL).Build()).Error()
+	if err != nil {
+		log.Error().Err(err).Str("webhook", d.WebhookID).Msg("Failed to store webhook delivery")
+		return
+	}
+	m.Bot.Schedule(deliveryQueue, id, at)
+}
+
+func (m *Module) post(hook *store.Webhook, eventType, deliveryID string, body []byte) (int, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.SetRequestURI(hook.URL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.Header.Set("User-Agent", m.Bot.Cfg.BotName+"/"+m.Bot.Cfg.BotVersion)
+	req.Header.Set("X-Lappbot-Event", eventType)
+	req.Header.Set("X-Lappbot-Delivery", deliveryID)
+	req.Header.Set("X-Lappbot-Timestamp", timestamp)
+	req.Header.Set("X-Lappbot-Signature", sign(hook.Secret, timestamp, body))
+	req.SetBody(body)
+
+	c := client
+	if hook.GroupID != 0 {
+		c = groupClient
+	}
+	if err := c.DoTimeout(req, resp, deliveryTimeout); err != nil {
+		return 0, err
+	}
+	status := resp.StatusCode()
+	if status < 200 || status >= 300 {
+		return status, errors.New("endpoint returned HTTP " + strconv.Itoa(status))
+	}
+	return status, nil
+}
+
+func (m *Module) deliver(id string) {
+	key := deliveryKey(id)
+	val, err := m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Get().Key(key).Build()).AsBytes()
+	if err != nil {
+		return
+	}
+	var d delivery
+	if err := json.Unmarshal(val, &d); err != nil {
+		m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
+		return
+	}
+
+	hook, err := m.Store.GetWebhook(d.WebhookID)
+	if err != nil {
+		m.Bot.Schedule(deliveryQueue, id, time.Now().Add(baseBackoff))
+		return
+	}
+	if hook == nil {
+		m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
+		return
+	}
+
+	d.Attempt++
+	_, err = m.post(hook, d.EventType, id, d.Body)
+	if err == nil {
+		m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
+		return
+	}
+
+	if d.Attempt >= maxAttempts {
+		m.Store.Valkey.Do(context.Background(), m.Store.Valkey.B().Del().Key(key).Build())
+		if err := m.Store.AddDeadLetter(hook.ID, d.EventType, d.Body, d.Attempt, err.Error()); err != nil {
+			log.Error().Err(err).Str("webhook", hook.ID).Msg("Failed to store dead letter")
+		}
+		return
+	}
+	m.schedule(id, &d, time.Now().Add(backoff(d.Attempt)))
+}
+
+func (m *Module) redeliver(d *store.DeadLetter) error {
+	var p payload
+	if err := json.Unmarshal(d.Payload, &p); err != nil || p.ID == "" {
+		return errors.New("stored payload is invalid")
+	}
+	m.schedule(p.ID, &delivery{WebhookID: d.WebhookID, EventType: d.EventType, Body: d.Payload}, time.Now())
+	return nil
+}
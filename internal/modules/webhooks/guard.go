@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+var errBlockedAddress = errors.New("destination address is not allowed")
+
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// groupClient is used for group-scoped hooks. It checks every resolved address
+// at dial time so a hostname can't be pointed at internal services later.
+var groupClient = &fasthttp.Client{
+	ReadTimeout:  deliveryTimeout,
+	WriteTimeout: deliveryTimeout,
+	Dial:         publicDial,
+}
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+func resolvePublic(host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return nil, errBlockedAddress
+		}
+		return []netip.Addr{addr}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errBlockedAddress
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return nil, errBlockedAddress
+		}
+	}
+	return addrs, nil
+}
+
+func publicDial(addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := resolvePublic(host)
+	if err != nil {
+		return nil, err
+	}
+	return fasthttp.DialTimeout(net.JoinHostPort(addrs[0].Unmap().String(), port), deliveryTimeout)
+}
+
+func checkGroupURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return errors.New("group webhooks must use https")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errBlockedAddress
+	}
+	_, err := resolvePublic(host)
+	return err
+}
@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"lappbot/internal/bot"
+	"lappbot/internal/modules/logging"
+	"lappbot/internal/store"
+)
+
+type Module struct {
+	Bot    *bot.Bot
+	Store  *store.Store
+	Logger *logging.Module
+}
+
+func New(b *bot.Bot, s *store.Store, l *logging.Module) *Module {
+	return &Module{Bot: b, Store: s, Logger: l}
+}
+
+const usage = `Usage:
+/webhooks - List endpoints
+/webhooks add <url> [event...] - Add an endpoint
+/webhooks events <id> <event...> - Change subscribed events
+/webhooks remove <id> - Remove an endpoint
+/webhooks test <id> - Send a test event
+/webhooks failed - List failed deliveries
+/webhooks retry <delivery id> - Retry a failed delivery
+
+Group webhooks must use https and point to a public address.
+
+Events: * (all), a category (admin, settings, user, automated, reports, other) or a type:
+Moderation: ban, unban, kick, mute, unmute, warn, warn_removed, warns_reset, realm_ban, realm_mute, approve, unapprove, promote, custom_title_failed, report, lock, unlock, pin, purge, message_deleted
+Members: member_join, member_leave, member_kicked, join_request_approved, join_request_declined, captcha_passed, welcome_mute_passed, welcome_mute_kick, chat_connected
+Automated: blacklist, flood, antiraid_triggered
+Content: note_saved, note_deleted, notes_cleared, filter_added, filter_deleted, blacklist_added, blacklist_removed, rules_updated, rules_reset, topic_created, topic_renamed, topic_closed, topic_reopened, topic_deleted
+Settings: settings_changed, relay_added, relay_rotated, relay_removed, webhook_added, webhook_removed, api_key_created, api_key_revoked, group_migrated`
+
+func (m *Module) Register() {
+	m.Bot.Handle("/webhooks", m.handleWebhooks)
+	m.Logger.Subscribe(m.onEvent)
+	go m.Bot.RunQueue(deliveryQueue, 5*time.Second, func(id string) {
+		go m.deliver(id)
+	})
+}
+
+func (m *Module) scope(c *bot.Context) (int64, bool) {
+	if c.Chat().Type != "private" {
+		c.Send("Webhooks can only be managed in PM. Connect to the group with /connect first.")
+		return 0, false
+	}
+	target, err := m.Bot.GetTargetChat(c)
+	if err != nil {
+		c.Send("Error resolving chat.")
+		return 0, false
+	}
+	if target.Type == "private" {
+		if c.Sender().ID != m.Bot.Cfg.BotOwnerID {
+			c.Send("Connect to a group with /connect first.")
+			return 0, false
+		}
+		return 0, true
+	}
+	if !m.Bot.CheckAdmin(c, target, c.Sender(), "can_change_info") {
+		return 0, false
+	}
+	return target.ID, true
+}
+
+func scopeName(groupID int64) string {
+	if groupID == 0 {
+		return "all groups (owner)"
+	}
+	return "chat " + strconv.FormatInt(groupID, 10)
+}
+
+func parseEvents(args []string) []string {
+	if len(args) == 0 {
+		return []string{"*"}
+	}
+	events := make([]string, 0, len(args))
+	for _, a := range args {
+		for _, e := range strings.Split(a, ",") {
+			if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+func (m *Module) handleWebhooks(c *bot.Context) error {
+	groupID, ok := m.scope(c)
+	if !ok {
+		return nil
+	}
+
+	sub := "list"
+	var args []string
+	if len(c.Args) > 0 {
+		sub = strings.ToLower(c.Args[0])
+		args = c.Args[1:]
+	}
+
+	switch sub {
+	case "list":
+		return m.list(c, groupID)
+	case "add":
+		return m.add(c, groupID, args)
+	case "events":
+		return m.setEvents(c, groupID, args)
+	case "remove", "rm", "delete":
+		return m.remove(c, groupID, args)
+	case "test":
+		return m.test(c, groupID, args)
+	case "failed":
+		return m.failed(c, groupID)
+	case "retry":
+		return m.retry(c, groupID, args)
+	}
+	return c.Send(usage)
+}
+
+func (m *Module) list(c *bot.Context, groupID int64) error {
+	hooks, err := m.Store.GetWebhooks(groupID)
+	if err != nil {
+		return c.Send("Failed to fetch webhooks: " + err.Error())
+	}
+	if len(hooks) == 0 {
+		return c.Send("No webhooks for " + scopeName(groupID) + ".\n\n" + usage)
+	}
+
+	msg := "Webhooks for " + scopeName(groupID) + ":\n"
+	for _, h := range hooks {
+		msg += "• " + h.ID + " - " + h.URL + " [" + strings.Join(h.Events, ", ") + "]\n"
+	}
+	return c.Send(msg)
+}
+
+func (m *Module) add(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /webhooks add <url> [event...]")
+	}
+	u, err := url.Parse(args[0])
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return c.Send("Invalid URL. Use an http(s) URL.")
+	}
+	if groupID != 0 {
+		if err := checkGroupURL(u); err != nil {
+			return c.Send("Invalid URL: " + err.Error() + ". Group webhooks must use https and point to a public address.")
+		}
+	}
+
+	hook, err := m.Store.CreateWebhook(groupID, u.String(), parseEvents(args[1:]), c.Sender().ID)
+	if err != nil {
+		return c.Send("Failed to add webhook: " + err.Error())
+	}
+	if groupID != 0 {
+		m.Logger.Emit(logging.Event{Type: "webhook_added", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "Webhook " + hook.ID})
+	}
+	return c.Send("Webhook added for "+scopeName(groupID)+".\nID: <code>"+hook.ID+"</code>\nEvents: "+strings.Join(hook.Events, ", ")+"\nSigning secret: <code>"+hook.Secret+"</code>\n\nEach delivery carries <code>X-Lappbot-Signature: sha256=HMAC(secret, timestamp + \".\" + body)</code> with the timestamp in <code>X-Lappbot-Timestamp</code>. This secret will not be shown again.", "HTML")
+}
+
+func (m *Module) setEvents(c *bot.Context, groupID int64, args []string) error {
+	if len(args) < 2 {
+		return c.Send("Usage: /webhooks events <id> <event...>")
+	}
+	events := parseEvents(args[1:])
+	updated, err := m.Store.SetWebhookEvents(groupID, args[0], events)
+	if err != nil {
+		return c.Send("Failed to update webhook: " + err.Error())
+	}
+	if !updated {
+		return c.Send("No webhook with that ID.")
+	}
+	return c.Send("Webhook " + args[0] + " now receives: " + strings.Join(events, ", "))
+}
+
+func (m *Module) remove(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /webhooks remove <id>")
+	}
+	deleted, err := m.Store.DeleteWebhook(groupID, args[0])
+	if err != nil {
+		return c.Send("Failed to remove webhook: " + err.Error())
+	}
+	if !deleted {
+		return c.Send("No webhook with that ID.")
+	}
+	if groupID != 0 {
+		m.Logger.Emit(logging.Event{Type: "webhook_removed", ChatID: groupID, Category: "settings", Actor: c.Sender(), Text: "Webhook " + args[0]})
+	}
+	return c.Send("Webhook removed.")
+}
+
+func (m *Module) test(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /webhooks test <id>")
+	}
+	hook, err := m.Store.GetWebhook(args[0])
+	if err != nil || hook == nil || hook.GroupID != groupID {
+		return c.Send("No webhook with that ID.")
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return c.Send("Failed to create test delivery.")
+	}
+	body, _ := json.Marshal(payload{ID: id, Event: logging.Event{
+		Type:     "test",
+		ChatID:   groupID,
+		Category: "other",
+		Actor:    c.Sender(),
+		Text:     "Test delivery from " + c.Sender().FirstName,
+		Time:     time.Now(),
+	}})
+
+	status, err := m.post(hook, "test", id, body)
+	if groupID != 0 {
+		if err != nil {
+			return c.Send("Test delivery failed.")
+		}
+		return c.Send("Test delivery succeeded.")
+	}
+	if err != nil {
+		return c.Send("Test delivery failed: " + err.Error())
+	}
+	return c.Send("Test delivery succeeded (HTTP " + strconv.Itoa(status) + ").")
+}
+
+func (m *Module) failed(c *bot.Context, groupID int64) error {
+	letters, err := m.Store.GetDeadLetters(groupID)
+	if err != nil {
+		return c.Send("Failed to fetch failed deliveries: " + err.Error())
+	}
+	if len(letters) == 0 {
+		return c.Send("No failed deliveries.")
+	}
+
+	msg := "Failed deliveries:\n"
+	for _, d := range letters {
+		msg += "• " + d.ID + " - " + d.EventType + " to " + d.WebhookID + " (" + strconv.Itoa(d.Attempts) + " attempts, " + d.CreatedAt.Format("2006-01-02 15:04") + ")\n"
+		if groupID == 0 {
+			msg += "  " + d.LastError + "\n"
+		}
+	}
+	return c.Send(msg + "\nUse /webhooks retry <delivery id> to try again.")
+}
+
+func (m *Module) retry(c *bot.Context, groupID int64, args []string) error {
+	if len(args) == 0 {
+		return c.Send("Usage: /webhooks retry <delivery id>")
+	}
+	d, err := m.Store.TakeDeadLetter(groupID, args[0])
+	if err != nil {
+		return c.Send("Failed to fetch delivery: " + err.Error())
+	}
+	if d == nil {
+		return c.Send("No failed delivery with that ID.")
+	}
+	if err := m.redeliver(d); err != nil {
+		return c.Send("Failed to retry delivery: " + err.Error())
+	}
+	return c.Send("Delivery queued for retry.")
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const apiKeyPrefix = "lpk_"
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	GroupID    int64      `json:"group_id"`
+	CreatedBy  int64      `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) CreateAPIKey(name string, groupID, createdBy int64) (string, *APIKey, error) {
+	id, err := gonanoid.New(12)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := gonanoid.New(40)
+	if err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	q := `INSERT INTO api_keys (id, key_hash, name, group_id, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+	k := &APIKey{ID: id, Name: name, GroupID: groupID, CreatedBy: createdBy}
+	err = s.db.QueryRow(context.Background(), q, id, hashAPIKey(key), name, groupID, createdBy).Scan(&k.CreatedAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, k, nil
+}
+
+func (s *Store) GetAPIKey(key string) (*APIKey, error) {
+	q := `UPDATE api_keys SET last_used_at = NOW() WHERE key_hash = $1
+          RETURNING id, COALESCE(name, ''), group_id, COALESCE(created_by, 0), last_used_at, created_at`
+	var k APIKey
+	err := s.db.QueryRow(context.Background(), q, hashAPIKey(key)).Scan(&k.ID, &k.Name, &k.GroupID, &k.CreatedBy, &k.LastUsedAt, &k.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (s *Store) GetAPIKeys(groupID int64) ([]APIKey, error) {
+	q := `SELECT id, COALESCE(name, ''), group_id, COALESCE(created_by, 0), last_used_at, created_at
+          FROM api_keys WHERE group_id = $1 ORDER BY created_at`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		var k APIKey
+		if err := rows.Scan(&k.ID, &k.Name, &k.GroupID, &k.CreatedBy, &k.LastUsedAt, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *Store) DeleteAPIKey(groupID int64, id string) (bool, error) {
+	q := `DELETE FROM api_keys WHERE group_id = $1 AND id = $2`
+	tag, err := s.db.Exec(context.Background(), q, groupID, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
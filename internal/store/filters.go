@@ -18,17 +18,18 @@ type Filter struct {
 	Trigger   string
 	Response  string
 	Type      string
+	Entities  json.RawMessage
 	CreatedAt any
 }
 
-func (s *Store) AddFilter(groupID int64, trigger, response, kind string) error {
+func (s *Store) AddFilter(groupID int64, trigger, response string, entities json.RawMessage, kind string) error {
 	id, err := gonanoid.New()
 	if err != nil {
 		return err
 	}
-	q := `INSERT INTO filters (id, group_id, trigger, response, type) VALUES ($1, $2, $3, $4, $5)
-	      ON CONFLICT (group_id, trigger) DO UPDATE SET response = $4, type = $5`
-	_, err = s.db.Exec(context.Background(), q, id, groupID, trigger, response, kind)
+	q := `INSERT INTO filters (id, group_id, trigger, response, type, entities) VALUES ($1, $2, $3, $4, $5, $6)
+	      ON CONFLICT (group_id, trigger) DO UPDATE SET response = $4, type = $5, entities = $6`
+	_, err = s.db.Exec(context.Background(), q, id, groupID, trigger, response, kind, entities)
 	if err == nil {
 		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("filters:"+strconv.FormatInt(groupID, 10)).Build())
 	}
@@ -58,7 +59,7 @@ func (s *Store) GetFilters(groupID int64) ([]Filter, error) {
 		}
 	}
 
-	q := `SELECT id, trigger, response, type FROM filters WHERE group_id = $1 ORDER BY trigger ASC`
+	q := `SELECT id, trigger, response, type, entities FROM filters WHERE group_id = $1 ORDER BY trigger ASC`
 	rows, err := s.db.Query(context.Background(), q, groupID)
 	if err != nil {
 		return nil, err
@@ -68,7 +69,7 @@ func (s *Store) GetFilters(groupID int64) ([]Filter, error) {
 	filters := make([]Filter, 0)
 	for rows.Next() {
 		var f Filter
-		if err := rows.Scan(&f.ID, &f.Trigger, &f.Response, &f.Type); err != nil {
+		if err := rows.Scan(&f.ID, &f.Trigger, &f.Response, &f.Type, &f.Entities); err != nil {
 			continue
 		}
 		filters = append(filters, f)
@@ -18,8 +18,14 @@ type Group struct {
 	Title                     string
 	GreetingEnabled           bool
 	GreetingMessage           string
+	GreetingType              string
+	GreetingFileID            string
+	GreetingEntities          json.RawMessage
 	GoodbyeEnabled            bool
 	GoodbyeMessage            string
+	GoodbyeType               string
+	GoodbyeFileID             string
+	GoodbyeEntities           json.RawMessage
 	CaptchaEnabled            bool
 	AntiraidUntil             *time.Time
 	RaidActionTime            string
@@ -36,7 +42,21 @@ type Group struct {
 	ActionTopicID             *int64
 	LogChannelID              int64
 	LogCategories             string
+	EvidenceCategories        string
+	EvidenceRetention         string
 	CleanCommands             string
+	CleanWelcome              bool
+	CleanService              string
+	WelcomeMute               string
+	WelcomeMuteTime           string
+	Rules                     string
+	RulesEntities             json.RawMessage
+	RulesPrivate              bool
+	RulesButton               string
+	AutoDelete                string
+	JoinRequestMode           string
+	Active                    bool
+	AddedBy                   int64
 	CreatedAt                 any
 }
 
@@ -53,7 +73,14 @@ func (s *Store) GetGroup(telegramID int64) (*Group, error) {
 	q := `SELECT id, telegram_id, title, greeting_enabled, greeting_message, goodbye_enabled, goodbye_message, captcha_enabled,
                  antiraid_until, raid_action_time, auto_antiraid_threshold,
                  antiflood_consecutive_limit, antiflood_timer_limit, antiflood_timer_duration, antiflood_action, antiflood_delete,
-                 warn_limit, warn_action, warn_duration, notes_private, action_topic_id, log_channel_id, log_categories, clean_commands
+                 warn_limit, warn_action, warn_duration, notes_private, action_topic_id, log_channel_id, log_categories, clean_commands,
+                 join_request_mode, COALESCE(active, true), COALESCE(added_by, 0),
+                 COALESCE(greeting_type, 'text'), COALESCE(greeting_file_id, ''), greeting_entities,
+                 COALESCE(goodbye_type, 'text'), COALESCE(goodbye_file_id, ''), goodbye_entities,
+                 COALESCE(clean_welcome, false), COALESCE(auto_delete, '{}'), COALESCE(clean_service, '[]'),
+                 COALESCE(welcome_mute, 'off'), COALESCE(welcome_mute_time, '5m'),
+                 COALESCE(rules, ''), rules_entities, COALESCE(rules_private, false), COALESCE(rules_button, 'Rules'),
+                 COALESCE(evidence_categories, '[]'), COALESCE(evidence_retention, 'off')
           FROM groups WHERE telegram_id = $1`
 
 	var g Group
@@ -63,6 +90,13 @@ func (s *Store) GetGroup(telegramID int64) (*Group, error) {
 		&g.AntiraidUntil, &g.RaidActionTime, &g.AutoAntiraidThreshold,
 		&g.AntifloodConsecutiveLimit, &g.AntifloodTimerLimit, &g.AntifloodTimerDuration, &g.AntifloodAction, &g.AntifloodDelete,
 		&g.WarnLimit, &g.WarnAction, &g.WarnDuration, &g.NotesPrivate, &g.ActionTopicID, &logChannelID, &g.LogCategories, &g.CleanCommands,
+		&g.JoinRequestMode, &g.Active, &g.AddedBy,
+		&g.GreetingType, &g.GreetingFileID, &g.GreetingEntities,
+		&g.GoodbyeType, &g.GoodbyeFileID, &g.GoodbyeEntities,
+		&g.CleanWelcome, &g.AutoDelete, &g.CleanService,
+		&g.WelcomeMute, &g.WelcomeMuteTime,
+		&g.Rules, &g.RulesEntities, &g.RulesPrivate, &g.RulesButton,
+		&g.EvidenceCategories, &g.EvidenceRetention,
 	)
 	if logChannelID != nil {
 		g.LogChannelID = *logChannelID
@@ -88,12 +122,18 @@ func (s *Store) CreateGroup(telegramID int64, title string) error {
 	}
 	q := `INSERT INTO groups (id, telegram_id, title, greeting_enabled, greeting_message, goodbye_enabled, goodbye_message, log_categories, clean_commands) 
           VALUES ($1, $2, $3, true, 'Welcome {firstname} (ID: {userid}) to the group!', true, 'Goodbye {firstname} (ID: {userid}), see you soon!', '["settings","admin","user","automated","reports","other"]', '[]') 
-          ON CONFLICT (telegram_id) DO UPDATE SET title = $3`
-	_, err = s.db.Exec(context.Background(), q, id, telegramID, title)
-	if err == nil {
-		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+          ON CONFLICT (telegram_id) DO UPDATE SET title = $3
+          RETURNING (xmax = 0)`
+	var inserted bool
+	err = s.db.QueryRow(context.Background(), q, id, telegramID, title).Scan(&inserted)
+	if err != nil {
+		return err
 	}
-	return err
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	if inserted {
+		return s.CopyRealmBans(telegramID)
+	}
+	return nil
 }
 
 func (s *Store) SetGreetingStatus(telegramID int64, enabled bool) error {
@@ -105,9 +145,9 @@ func (s *Store) SetGreetingStatus(telegramID int64, enabled bool) error {
 	return err
 }
 
-func (s *Store) SetGreetingMessage(telegramID int64, message string) error {
-	q := `UPDATE groups SET greeting_message = $1 WHERE telegram_id = $2`
-	_, err := s.db.Exec(context.Background(), q, message, telegramID)
+func (s *Store) SetGreetingMessage(telegramID int64, message string, entities json.RawMessage, kind, fileID string) error {
+	q := `UPDATE groups SET greeting_message = $1, greeting_entities = $2, greeting_type = $3, greeting_file_id = $4 WHERE telegram_id = $5`
+	_, err := s.db.Exec(context.Background(), q, message, entities, kind, fileID, telegramID)
 	if err == nil {
 		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
 	}
@@ -123,9 +163,9 @@ func (s *Store) SetGoodbyeStatus(telegramID int64, enabled bool) error {
 	return err
 }
 
-func (s *Store) SetGoodbyeMessage(telegramID int64, message string) error {
-	q := `UPDATE groups SET goodbye_message = $1 WHERE telegram_id = $2`
-	_, err := s.db.Exec(context.Background(), q, message, telegramID)
+func (s *Store) SetGoodbyeMessage(telegramID int64, message string, entities json.RawMessage, kind, fileID string) error {
+	q := `UPDATE groups SET goodbye_message = $1, goodbye_entities = $2, goodbye_type = $3, goodbye_file_id = $4 WHERE telegram_id = $5`
+	_, err := s.db.Exec(context.Background(), q, message, entities, kind, fileID, telegramID)
 	if err == nil {
 		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
 	}
@@ -261,3 +301,94 @@ func (s *Store) SetCleanCommands(telegramID int64, commands []string) error {
 	}
 	return err
 }
+
+func (s *Store) SetJoinRequestMode(telegramID int64, mode string) error {
+	q := `UPDATE groups SET join_request_mode = $1 WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, mode, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetCleanService(telegramID int64, types []string) error {
+	data, err := json.Marshal(types)
+	if err != nil {
+		return err
+	}
+	q := `UPDATE groups SET clean_service = $1 WHERE telegram_id = $2`
+	_, err = s.db.Exec(context.Background(), q, string(data), telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetWelcomeMute(telegramID int64, mode, duration string) error {
+	q := `UPDATE groups SET welcome_mute = $1, welcome_mute_time = $2 WHERE telegram_id = $3`
+	_, err := s.db.Exec(context.Background(), q, mode, duration, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetRules(telegramID int64, rules string, entities json.RawMessage) error {
+	q := `UPDATE groups SET rules = $1, rules_entities = $2 WHERE telegram_id = $3`
+	_, err := s.db.Exec(context.Background(), q, rules, entities, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetRulesPrivate(telegramID int64, enabled bool) error {
+	q := `UPDATE groups SET rules_private = $1 WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, enabled, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetRulesButton(telegramID int64, text string) error {
+	q := `UPDATE groups SET rules_button = $1 WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, text, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetCleanWelcome(telegramID int64, enabled bool) error {
+	q := `UPDATE groups SET clean_welcome = $1 WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, enabled, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetAutoDelete(telegramID int64, delays map[string]string) error {
+	data, err := json.Marshal(delays)
+	if err != nil {
+		return err
+	}
+	q := `UPDATE groups SET auto_delete = $1 WHERE telegram_id = $2`
+	_, err = s.db.Exec(context.Background(), q, string(data), telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (g *Group) AutoDeleteDelays() map[string]string {
+	delays := make(map[string]string)
+	json.Unmarshal([]byte(g.AutoDelete), &delays)
+	return delays
+}
+
+func (g *Group) AutoDeleteAfter(kind string) time.Duration {
+	d, _ := time.ParseDuration(g.AutoDeleteDelays()[kind])
+	return d
+}
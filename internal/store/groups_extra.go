@@ -2,10 +2,11 @@ package store
 
 import (
 	"context"
+	"strconv"
 )
 
 func (s *Store) GetAllGroups() ([]Group, error) {
-	q := `SELECT id, telegram_id, title FROM groups`
+	q := `SELECT id, telegram_id, title FROM groups WHERE COALESCE(active, true)`
 	rows, err := s.db.Query(context.Background(), q)
 	if err != nil {
 		return nil, err
@@ -22,3 +23,102 @@ func (s *Store) GetAllGroups() ([]Group, error) {
 	}
 	return groups, nil
 }
+
+func (s *Store) GetGroupRegistry() ([]Group, error) {
+	q := `SELECT id, telegram_id, COALESCE(title, ''), COALESCE(active, true), COALESCE(added_by, 0)
+	      FROM groups ORDER BY active DESC, title ASC`
+	rows, err := s.db.Query(context.Background(), q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]Group, 0)
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.ID, &g.TelegramID, &g.Title, &g.Active, &g.AddedBy); err != nil {
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (s *Store) RegisterGroup(telegramID int64, title string, addedBy int64) error {
+	if err := s.CreateGroup(telegramID, title); err != nil {
+		return err
+	}
+	q := `UPDATE groups SET active = true, added_by = $1, left_at = NULL WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, addedBy, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetGroupInactive(telegramID int64) error {
+	q := `UPDATE groups SET active = false, left_at = NOW() WHERE telegram_id = $1`
+	_, err := s.db.Exec(context.Background(), q, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) MigrateGroup(oldID, newID int64) (bool, error) {
+	ctx := context.Background()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := []string{
+		`DELETE FROM groups WHERE telegram_id = $2 AND EXISTS (SELECT 1 FROM groups WHERE telegram_id = $1)`,
+		`UPDATE groups SET telegram_id = $2 WHERE telegram_id = $1`,
+		`UPDATE notes SET chat_id = $2 WHERE chat_id = $1 AND name NOT IN (SELECT name FROM notes WHERE chat_id = $2)`,
+		`DELETE FROM notes WHERE chat_id = $1`,
+		`UPDATE filters SET group_id = $2 WHERE group_id = $1 AND trigger NOT IN (SELECT trigger FROM filters WHERE group_id = $2)`,
+		`DELETE FROM filters WHERE group_id = $1`,
+		`UPDATE blacklists b SET group_id = $2 WHERE b.group_id = $1 AND NOT EXISTS (SELECT 1 FROM blacklists n WHERE n.group_id = $2 AND n.type = b.type AND n.value = b.value)`,
+		`DELETE FROM blacklists WHERE group_id = $1`,
+		`UPDATE approved_users SET group_id = $2 WHERE group_id = $1 AND user_id NOT IN (SELECT user_id FROM approved_users WHERE group_id = $2)`,
+		`DELETE FROM approved_users WHERE group_id = $1`,
+		`UPDATE warns SET group_id = $2 WHERE group_id = $1`,
+		`UPDATE bans SET group_id = $2 WHERE group_id = $1`,
+		`UPDATE log_routes SET group_id = $2 WHERE group_id = $1 AND category NOT IN (SELECT category FROM log_routes WHERE group_id = $2)`,
+		`DELETE FROM log_routes WHERE group_id = $1`,
+		`UPDATE api_keys SET group_id = $2 WHERE group_id = $1`,
+		`UPDATE webhooks SET group_id = $2 WHERE group_id = $1`,
+		`UPDATE relays SET group_id = $2 WHERE group_id = $1`,
+	}
+	var moved int64
+	for _, q := range queries {
+		tag, err := tx.Exec(ctx, q, oldID, newID)
+		if err != nil {
+			return false, err
+		}
+		if tag.Update() {
+			moved += tag.RowsAffected()
+		}
+	}
+	if moved == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	for _, id := range []int64{oldID, newID} {
+		idStr := strconv.FormatInt(id, 10)
+		s.Valkey.Do(ctx, s.Valkey.B().Del().Key("group:"+idStr, "notes:"+idStr, "filters:"+idStr, "blacklist:"+idStr, logRoutesKey(id)).Build())
+		s.invalidateWebhooks(id)
+	}
+	if relays, err := s.GetRelays(newID); err == nil {
+		for _, r := range relays {
+			s.Valkey.Do(ctx, s.Valkey.B().Del().Key(relayKey(r.ID)).Build())
+		}
+	}
+	return true, nil
+}
@@ -3,8 +3,11 @@ package store
 import (
 	"context"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/goccy/go-json"
+	gonanoid "github.com/matoous/go-nanoid/v2"
 )
 
 func (s *Store) SetLogChannel(telegramID int64, channelID int64) error {
@@ -28,3 +31,164 @@ func (s *Store) SetLogCategories(telegramID int64, categories []string) error {
 	}
 	return err
 }
+
+func (s *Store) SetEvidenceCategories(telegramID int64, categories []string) error {
+	data, err := json.Marshal(categories)
+	if err != nil {
+		return err
+	}
+	q := `UPDATE groups SET evidence_categories = $1 WHERE telegram_id = $2`
+	_, err = s.db.Exec(context.Background(), q, string(data), telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+func (s *Store) SetEvidenceRetention(telegramID int64, retention string) error {
+	q := `UPDATE groups SET evidence_retention = $1 WHERE telegram_id = $2`
+	_, err := s.db.Exec(context.Background(), q, retention, telegramID)
+	if err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("group:"+strconv.FormatInt(telegramID, 10)).Build())
+	}
+	return err
+}
+
+type LogRoute struct {
+	Category string `json:"category"`
+	ChatID   int64  `json:"chat_id"`
+	ThreadID int64  `json:"thread_id"`
+}
+
+func logRoutesKey(groupID int64) string {
+	return "log_routes:" + strconv.FormatInt(groupID, 10)
+}
+
+func (s *Store) GetLogRoutes(groupID int64) ([]LogRoute, error) {
+	val, err := s.Valkey.Do(context.Background(), s.Valkey.B().Get().Key(logRoutesKey(groupID)).Build()).AsBytes()
+	if err == nil {
+		var routes []LogRoute
+		if err := json.Unmarshal(val, &routes); err == nil {
+			return routes, nil
+		}
+	}
+
+	q := `SELECT category, chat_id, thread_id FROM log_routes WHERE group_id = $1 ORDER BY category`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := make([]LogRoute, 0)
+	for rows.Next() {
+		var r LogRoute
+		if err := rows.Scan(&r.Category, &r.ChatID, &r.ThreadID); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(routes); err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Set().Key(logRoutesKey(groupID)).Value(string(data)).Ex(10*time.Minute).Build())
+	}
+	return routes, nil
+}
+
+func (s *Store) SetLogRoute(groupID int64, category string, chatID, threadID int64) error {
+	q := `INSERT INTO log_routes (group_id, category, chat_id, thread_id) VALUES ($1, $2, $3, $4)
+          ON CONFLICT (group_id, category) DO UPDATE SET chat_id = $3, thread_id = $4`
+	_, err := s.db.Exec(context.Background(), q, groupID, category, chatID, threadID)
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(logRoutesKey(groupID)).Build())
+	return err
+}
+
+func (s *Store) DeleteLogRoute(groupID int64, category string) (bool, error) {
+	q := `DELETE FROM log_routes WHERE group_id = $1 AND category = $2`
+	tag, err := s.db.Exec(context.Background(), q, groupID, category)
+	if err != nil {
+		return false, err
+	}
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(logRoutesKey(groupID)).Build())
+	return tag.RowsAffected() > 0, nil
+}
+
+type LoggedGroup struct {
+	TelegramID int64
+	Title      string
+}
+
+func (s *Store) GetGroupsLoggingTo(chatID int64) ([]LoggedGroup, error) {
+	q := `SELECT telegram_id, COALESCE(title, '') FROM groups WHERE log_channel_id = $1
+          UNION
+          SELECT g.telegram_id, COALESCE(g.title, '') FROM log_routes r JOIN groups g ON g.telegram_id = r.group_id WHERE r.chat_id = $1
+          ORDER BY 2`
+	rows, err := s.db.Query(context.Background(), q, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]LoggedGroup, 0)
+	for rows.Next() {
+		var g LoggedGroup
+		if err := rows.Scan(&g.TelegramID, &g.Title); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func logLinkKey(code string) string {
+	return "loglink:" + code
+}
+
+func (s *Store) CreateLogLinkCode(chatID int64) (string, error) {
+	code, err := gonanoid.Generate("ABCDEFGHJKLMNPQRSTUVWXYZ23456789", 8)
+	if err != nil {
+		return "", err
+	}
+	err = s.Valkey.Do(context.Background(), s.Valkey.B().Set().Key(logLinkKey(code)).Value(strconv.FormatInt(chatID, 10)).Ex(10*time.Minute).Build()).Error()
+	return code, err
+}
+
+func (s *Store) TakeLogLinkCode(code string) (int64, error) {
+	return s.Valkey.Do(context.Background(), s.Valkey.B().Getdel().Key(logLinkKey(strings.ToUpper(code))).Build()).AsInt64()
+}
+
+func logPromptKey(chatID int64) string {
+	return "loglink_prompt:" + strconv.FormatInt(chatID, 10)
+}
+
+func (s *Store) SetLogLinkPrompt(chatID, messageID int64) error {
+	return s.Valkey.Do(context.Background(), s.Valkey.B().Set().Key(logPromptKey(chatID)).Value(strconv.FormatInt(messageID, 10)).Ex(10*time.Minute).Build()).Error()
+}
+
+func (s *Store) TakeLogLinkPrompt(chatID, messageID int64) bool {
+	key := logPromptKey(chatID)
+	val, err := s.Valkey.Do(context.Background(), s.Valkey.B().Get().Key(key).Build()).AsInt64()
+	if err != nil || val != messageID {
+		return false
+	}
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(key).Build())
+	return true
+}
+
+func logFailKey(groupID, chatID int64) string {
+	return "logfail:" + strconv.FormatInt(groupID, 10) + ":" + strconv.FormatInt(chatID, 10)
+}
+
+func (s *Store) RecordLogFailure(groupID, chatID int64) int64 {
+	key := logFailKey(groupID, chatID)
+	count, _ := s.Valkey.Do(context.Background(), s.Valkey.B().Incr().Key(key).Build()).AsInt64()
+	s.Valkey.Do(context.Background(), s.Valkey.B().Expire().Key(key).Seconds(int64((24 * time.Hour).Seconds())).Build())
+	return count
+}
+
+func (s *Store) ClearLogFailures(groupID, chatID int64) {
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(logFailKey(groupID, chatID)).Build())
+}
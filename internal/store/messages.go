@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	MessageIndexSize = 2000
+	MessageIndexTTL  = 48 * time.Hour
+)
+
+type IndexedMessage struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"u"`
+	Date     int64  `json:"d"`
+	Type     string `json:"t"`
+	Links    bool   `json:"l,omitempty"`
+	ThreadID int64  `json:"th,omitempty"`
+}
+
+func messageIndexKey(chatID int64) string {
+	return "msgindex:" + strconv.FormatInt(chatID, 10)
+}
+
+func (s *Store) IndexMessage(chatID int64, msg IndexedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	key := messageIndexKey(chatID)
+	id := strconv.FormatInt(msg.ID, 10)
+
+	cmds := make(valkey.Commands, 0, 4)
+	cmds = append(cmds, s.Valkey.B().Zremrangebyscore().Key(key).Min(id).Max(id).Build())
+	cmds = append(cmds, s.Valkey.B().Zadd().Key(key).ScoreMember().ScoreMember(float64(msg.ID), string(data)).Build())
+	cmds = append(cmds, s.Valkey.B().Zremrangebyrank().Key(key).Start(0).Stop(-MessageIndexSize-1).Build())
+	cmds = append(cmds, s.Valkey.B().Expire().Key(key).Seconds(int64(MessageIndexTTL.Seconds())).Build())
+	for _, resp := range s.Valkey.DoMulti(context.Background(), cmds...) {
+		if err := resp.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIndexedMessages returns the indexed messages of a chat that are still
+// young enough to be deleted, oldest first.
+func (s *Store) GetIndexedMessages(chatID int64) ([]IndexedMessage, error) {
+	vals, err := s.Valkey.Do(context.Background(), s.Valkey.B().Zrange().Key(messageIndexKey(chatID)).Min("0").Max("-1").Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-MessageIndexTTL).Unix()
+	msgs := make([]IndexedMessage, 0, len(vals))
+	for _, v := range vals {
+		var msg IndexedMessage
+		if err := json.Unmarshal([]byte(v), &msg); err == nil && msg.Date > cutoff {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+func (s *Store) UnindexMessages(chatID int64, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	key := messageIndexKey(chatID)
+	cmds := make(valkey.Commands, 0, len(ids))
+	for _, id := range ids {
+		score := strconv.FormatInt(id, 10)
+		cmds = append(cmds, s.Valkey.B().Zremrangebyscore().Key(key).Min(score).Max(score).Build())
+	}
+	s.Valkey.DoMulti(context.Background(), cmds...)
+}
@@ -37,6 +37,30 @@ func (s *Store) GetWarnCount(userID, groupID int64) (int, error) {
 	return count, err
 }
 
+type WarnStat struct {
+	UserID int64 `json:"user_id"`
+	Count  int   `json:"count"`
+}
+
+func (s *Store) GetWarnStats(groupID int64) ([]WarnStat, error) {
+	q := `SELECT user_id, COUNT(*) FROM warns WHERE group_id = $1 GROUP BY user_id ORDER BY COUNT(*) DESC`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []WarnStat
+	for rows.Next() {
+		var w WarnStat
+		if err := rows.Scan(&w.UserID, &w.Count); err != nil {
+			return nil, err
+		}
+		stats = append(stats, w)
+	}
+	return stats, rows.Err()
+}
+
 func (s *Store) ResetWarns(userID, groupID int64) error {
 	q := `DELETE FROM warns WHERE user_id = $1 AND group_id = $2`
 	_, err := s.db.Exec(context.Background(), q, userID, groupID)
@@ -82,6 +106,61 @@ func (s *Store) BanUser(userID, groupID int64, until time.Time, reason string, c
 	return err
 }
 
+func (s *Store) RemoveBans(userID, groupID int64) error {
+	q := `DELETE FROM bans WHERE user_id = $1 AND group_id = $2 AND type IN ('ban', 'realm_ban')`
+	_, err := s.db.Exec(context.Background(), q, userID, groupID)
+	return err
+}
+
+// CopyRealmBans gives a newly created group its own row for every active
+// realm ban, so /unban there can lift it for that group alone.
+func (s *Store) CopyRealmBans(groupID int64) error {
+	q := `SELECT DISTINCT ON (user_id) user_id, until_date, COALESCE(reason, ''), COALESCE(created_by, 0) FROM bans
+          WHERE type = 'realm_ban' AND group_id <> $1 AND (until_date IS NULL OR until_date > NOW())
+          ORDER BY user_id, created_at DESC`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return err
+	}
+	type realmBan struct {
+		userID, createdBy int64
+		until             *time.Time
+		reason            string
+	}
+	var bans []realmBan
+	for rows.Next() {
+		var b realmBan
+		if err := rows.Scan(&b.userID, &b.until, &b.reason, &b.createdBy); err != nil {
+			rows.Close()
+			return err
+		}
+		bans = append(bans, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, b := range bans {
+		var until time.Time
+		if b.until != nil {
+			until = *b.until
+		}
+		if err := s.BanUser(b.userID, groupID, until, b.reason, b.createdBy, "realm_ban"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) IsRealmBanned(userID, groupID int64) (bool, error) {
+	q := `SELECT EXISTS(SELECT 1 FROM bans WHERE user_id = $1 AND group_id = $2 AND type = 'realm_ban'
+          AND (until_date IS NULL OR until_date > NOW()))`
+	var exists bool
+	err := s.db.QueryRow(context.Background(), q, userID, groupID).Scan(&exists)
+	return exists, err
+}
+
 type BlacklistItem struct {
 	ID             string    `db:"id" json:"id"`
 	GroupID        int64     `db:"group_id" json:"group_id"`
@@ -205,3 +284,34 @@ func (s *Store) GetApprovedUsers(groupID int64) ([]int64, error) {
 	}
 	return users, nil
 }
+
+type Ban struct {
+	ID        string     `json:"id"`
+	UserID    int64      `json:"user_id"`
+	GroupID   int64      `json:"group_id"`
+	Type      string     `json:"type"`
+	Reason    string     `json:"reason"`
+	UntilDate *time.Time `json:"until_date,omitempty"`
+	CreatedBy int64      `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (s *Store) GetBans(groupID int64) ([]Ban, error) {
+	q := `SELECT id, user_id, group_id, type, COALESCE(reason, ''), until_date, COALESCE(created_by, 0), created_at
+          FROM bans WHERE group_id = $1 ORDER BY created_at DESC`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := make([]Ban, 0)
+	for rows.Next() {
+		var b Ban
+		if err := rows.Scan(&b.ID, &b.UserID, &b.GroupID, &b.Type, &b.Reason, &b.UntilDate, &b.CreatedBy, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
@@ -19,19 +19,20 @@ type Note struct {
 	Content   string
 	Type      string
 	FileID    string
+	Entities  json.RawMessage
 	CreatedBy int64
 	CreatedAt any
 }
 
-func (s *Store) SaveNote(chatID int64, name, content, noteType, fileID string, createdBy int64) error {
+func (s *Store) SaveNote(chatID int64, name, content string, entities json.RawMessage, noteType, fileID string, createdBy int64) error {
 	id, err := gonanoid.New()
 	if err != nil {
 		return err
 	}
-	q := `INSERT INTO notes (id, chat_id, name, content, type, file_id, created_by) 
-          VALUES ($1, $2, $3, $4, $5, $6, $7)
-          ON CONFLICT (chat_id, name) DO UPDATE SET content = $4, type = $5, file_id = $6, created_by = $7`
-	_, err = s.db.Exec(context.Background(), q, id, chatID, name, content, noteType, fileID, createdBy)
+	q := `INSERT INTO notes (id, chat_id, name, content, type, file_id, created_by, entities) 
+          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+          ON CONFLICT (chat_id, name) DO UPDATE SET content = $4, type = $5, file_id = $6, created_by = $7, entities = $8`
+	_, err = s.db.Exec(context.Background(), q, id, chatID, name, content, noteType, fileID, createdBy, entities)
 	if err == nil {
 		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("notes:"+strconv.FormatInt(chatID, 10)).Build())
 		s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key("note:"+strconv.FormatInt(chatID, 10)+":"+name).Build())
@@ -49,10 +50,10 @@ func (s *Store) GetNote(chatID int64, name string) (*Note, error) {
 		}
 	}
 
-	q := `SELECT id, chat_id, name, content, type, file_id, created_by FROM notes WHERE chat_id = $1 AND name = $2`
+	q := `SELECT id, chat_id, name, content, type, file_id, created_by, entities FROM notes WHERE chat_id = $1 AND name = $2`
 	var n Note
 	err = s.db.QueryRow(context.Background(), q, chatID, name).Scan(
-		&n.ID, &n.ChatID, &n.Name, &n.Content, &n.Type, &n.FileID, &n.CreatedBy,
+		&n.ID, &n.ChatID, &n.Name, &n.Content, &n.Type, &n.FileID, &n.CreatedBy, &n.Entities,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jackc/pgx/v5"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const relaySecretPrefix = "lpr_"
+
+type Relay struct {
+	ID         string    `json:"id"`
+	GroupID    int64     `json:"group_id"`
+	Name       string    `json:"name"`
+	SecretHash string    `json:"secret_hash"`
+	ThreadID   int64     `json:"thread_id"`
+	Template   string    `json:"template"`
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RelayDelivery struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	MessageID int64     `json:"message_id,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+func (r *Relay) CheckSecret(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashRelaySecret(secret)), []byte(r.SecretHash)) == 1
+}
+
+func hashRelaySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRelaySecret() (string, error) {
+	secret, err := gonanoid.New(40)
+	if err != nil {
+		return "", err
+	}
+	return relaySecretPrefix + secret, nil
+}
+
+func relayKey(id string) string {
+	return "relay:" + id
+}
+
+func relayLogKey(id string) string {
+	return "relay_log:" + id
+}
+
+func (s *Store) invalidateRelay(id string) {
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(relayKey(id)).Build())
+}
+
+const relayColumns = `id, group_id, COALESCE(name, ''), secret_hash, thread_id, COALESCE(template, ''), COALESCE(created_by, 0), created_at`
+
+func scanRelay(row pgx.Row) (*Relay, error) {
+	var r Relay
+	if err := row.Scan(&r.ID, &r.GroupID, &r.Name, &r.SecretHash, &r.ThreadID, &r.Template, &r.CreatedBy, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Store) CreateRelay(groupID int64, name string, createdBy int64) (string, *Relay, error) {
+	id, err := gonanoid.New(12)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := newRelaySecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	q := `INSERT INTO relays (id, group_id, name, secret_hash, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING ` + relayColumns
+	r, err := scanRelay(s.db.QueryRow(context.Background(), q, id, groupID, name, hashRelaySecret(secret), createdBy))
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, r, nil
+}
+
+func (s *Store) GetRelay(id string) (*Relay, error) {
+	val, err := s.Valkey.Do(context.Background(), s.Valkey.B().Get().Key(relayKey(id)).Build()).AsBytes()
+	if err == nil {
+		var r Relay
+		if err := json.Unmarshal(val, &r); err == nil {
+			return &r, nil
+		}
+	}
+
+	q := `SELECT ` + relayColumns + ` FROM relays WHERE id = $1`
+	r, err := scanRelay(s.db.QueryRow(context.Background(), q, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if data, err := json.Marshal(r); err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Set().Key(relayKey(id)).Value(string(data)).Ex(10*time.Minute).Build())
+	}
+	return r, nil
+}
+
+func (s *Store) GetRelays(groupID int64) ([]Relay, error) {
+	q := `SELECT ` + relayColumns + ` FROM relays WHERE group_id = $1 ORDER BY created_at`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relays := make([]Relay, 0)
+	for rows.Next() {
+		r, err := scanRelay(rows)
+		if err != nil {
+			return nil, err
+		}
+		relays = append(relays, *r)
+	}
+	return relays, rows.Err()
+}
+
+func (s *Store) updateRelay(groupID int64, id, set string, value any) (bool, error) {
+	q := `UPDATE relays SET ` + set + ` = $1 WHERE group_id = $2 AND id = $3`
+	tag, err := s.db.Exec(context.Background(), q, value, groupID, id)
+	if err != nil {
+		return false, err
+	}
+	s.invalidateRelay(id)
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) SetRelayTemplate(groupID int64, id, template string) (bool, error) {
+	return s.updateRelay(groupID, id, "template", template)
+}
+
+func (s *Store) SetRelayThread(groupID int64, id string, threadID int64) (bool, error) {
+	return s.updateRelay(groupID, id, "thread_id", threadID)
+}
+
+func (s *Store) RotateRelaySecret(groupID int64, id string) (string, bool, error) {
+	secret, err := newRelaySecret()
+	if err != nil {
+		return "", false, err
+	}
+	updated, err := s.updateRelay(groupID, id, "secret_hash", hashRelaySecret(secret))
+	if err != nil || !updated {
+		return "", updated, err
+	}
+	return secret, true, nil
+}
+
+func (s *Store) DeleteRelay(groupID int64, id string) (bool, error) {
+	q := `DELETE FROM relays WHERE group_id = $1 AND id = $2`
+	tag, err := s.db.Exec(context.Background(), q, groupID, id)
+	if err != nil {
+		return false, err
+	}
+	s.invalidateRelay(id)
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(relayLogKey(id)).Build())
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) AllowRelay(id string, limit int64, window time.Duration) bool {
+	key := "relay_rl:" + id + ":" + strconv.FormatInt(time.Now().Unix()/int64(window.Seconds()), 10)
+	count, err := s.Valkey.Do(context.Background(), s.Valkey.B().Incr().Key(key).Build()).AsInt64()
+	if err != nil {
+		return true
+	}
+	if count == 1 {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Expire().Key(key).Seconds(int64(window.Seconds())).Build())
+	}
+	return count <= limit
+}
+
+func (s *Store) AddRelayDelivery(id string, d RelayDelivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	key := relayLogKey(id)
+	s.Valkey.Do(context.Background(), s.Valkey.B().Lpush().Key(key).Element(string(data)).Build())
+	s.Valkey.Do(context.Background(), s.Valkey.B().Expire().Key(key).Seconds(int64((7 * 24 * time.Hour).Seconds())).Build())
+	return s.Valkey.Do(context.Background(), s.Valkey.B().Ltrim().Key(key).Start(0).Stop(49).Build()).Error()
+}
+
+func (s *Store) GetRelayDeliveries(id string, limit int64) ([]RelayDelivery, error) {
+	vals, err := s.Valkey.Do(context.Background(), s.Valkey.B().Lrange().Key(relayLogKey(id)).Start(0).Stop(limit-1).Build()).AsStrSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]RelayDelivery, 0, len(vals))
+	for _, v := range vals {
+		var d RelayDelivery
+		if err := json.Unmarshal([]byte(v), &d); err == nil {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/jackc/pgx/v5"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	GroupID   int64     `json:"group_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeadLetter struct {
+	ID        string
+	WebhookID string
+	EventType string
+	Payload   json.RawMessage
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
+
+func webhooksKey(groupID int64) string {
+	return "webhooks:" + strconv.FormatInt(groupID, 10)
+}
+
+func (s *Store) invalidateWebhooks(groupID int64) {
+	s.Valkey.Do(context.Background(), s.Valkey.B().Del().Key(webhooksKey(groupID)).Build())
+}
+
+func scanWebhook(row pgx.Row) (*Webhook, error) {
+	var w Webhook
+	var events string
+	if err := row.Scan(&w.ID, &w.GroupID, &w.URL, &w.Secret, &events, &w.CreatedBy, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(events), &w.Events)
+	return &w, nil
+}
+
+const webhookColumns = `id, group_id, url, secret, COALESCE(events, '["*"]'), COALESCE(created_by, 0), created_at`
+
+func (s *Store) CreateWebhook(groupID int64, url string, events []string, createdBy int64) (*Webhook, error) {
+	id, err := gonanoid.New(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := gonanoid.New(32)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `INSERT INTO webhooks (id, group_id, url, secret, events, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + webhookColumns
+	w, err := scanWebhook(s.db.QueryRow(context.Background(), q, id, groupID, url, secret, string(data), createdBy))
+	if err == nil {
+		s.invalidateWebhooks(groupID)
+	}
+	return w, err
+}
+
+func (s *Store) GetWebhook(id string) (*Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	w, err := scanWebhook(s.db.QueryRow(context.Background(), q, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return w, err
+}
+
+func (s *Store) GetWebhooks(groupID int64) ([]Webhook, error) {
+	val, err := s.Valkey.Do(context.Background(), s.Valkey.B().Get().Key(webhooksKey(groupID)).Build()).AsBytes()
+	if err == nil {
+		var hooks []Webhook
+		if err := json.Unmarshal(val, &hooks); err == nil {
+			return hooks, nil
+		}
+	}
+
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE group_id = $1 ORDER BY created_at`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(hooks); err == nil {
+		s.Valkey.Do(context.Background(), s.Valkey.B().Set().Key(webhooksKey(groupID)).Value(string(data)).Ex(10*time.Minute).Build())
+	}
+	return hooks, nil
+}
+
+func (s *Store) SetWebhookEvents(groupID int64, id string, events []string) (bool, error) {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return false, err
+	}
+	q := `UPDATE webhooks SET events = $1 WHERE group_id = $2 AND id = $3`
+	tag, err := s.db.Exec(context.Background(), q, string(data), groupID, id)
+	if err != nil {
+		return false, err
+	}
+	s.invalidateWebhooks(groupID)
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) DeleteWebhook(groupID int64, id string) (bool, error) {
+	q := `DELETE FROM webhooks WHERE group_id = $1 AND id = $2`
+	tag, err := s.db.Exec(context.Background(), q, groupID, id)
+	if err != nil {
+		return false, err
+	}
+	s.invalidateWebhooks(groupID)
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) AddDeadLetter(webhookID, eventType string, payload []byte, attempts int, lastError string) error {
+	id, err := gonanoid.New(12)
+	if err != nil {
+		return err
+	}
+	q := `INSERT INTO webhook_dead_letters (id, webhook_id, event_type, payload, attempts, last_error) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = s.db.Exec(context.Background(), q, id, webhookID, eventType, payload, attempts, lastError)
+	return err
+}
+
+func (s *Store) GetDeadLetters(groupID int64) ([]DeadLetter, error) {
+	q := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.attempts, COALESCE(d.last_error, ''), d.created_at
+          FROM webhook_dead_letters d JOIN webhooks w ON w.id = d.webhook_id
+          WHERE w.group_id = $1 ORDER BY d.created_at DESC LIMIT 50`
+	rows, err := s.db.Query(context.Background(), q, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := make([]DeadLetter, 0)
+	for rows.Next() {
+		var d DeadLetter
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		letters = append(letters, d)
+	}
+	return letters, rows.Err()
+}
+
+func (s *Store) TakeDeadLetter(groupID int64, id string) (*DeadLetter, error) {
+	q := `DELETE FROM webhook_dead_letters d USING webhooks w
+          WHERE d.id = $1 AND w.id = d.webhook_id AND w.group_id = $2
+          RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.attempts, COALESCE(d.last_error, ''), d.created_at`
+	var d DeadLetter
+	err := s.db.QueryRow(context.Background(), q, id, groupID).Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Attempts, &d.LastError, &d.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,453 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"html"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-json"
+
+	"lappbot/internal/bot"
+)
+
+const (
+	Markdown   = "Markdown"
+	MarkdownV2 = "MarkdownV2"
+	HTML       = "HTML"
+
+	NoteButtonPrefix = "get_note"
+
+	noteRefPrefix = "\x01"
+)
+
+type Data struct {
+	Bot         *bot.Bot
+	User        *bot.User
+	Chat        *bot.Chat
+	Count       int
+	RulesButton string
+}
+
+type Message struct {
+	Text                string
+	ParseMode           string
+	DisablePreview      bool
+	DisableNotification bool
+	Protect             bool
+	Rules               bool
+	Markup              *bot.ReplyMarkup
+	Entities            []bot.MessageEntity
+}
+
+type noteButton struct {
+	row, col int
+	name     string
+}
+
+var buttonPattern = regexp.MustCompile(`\[([^\[\]]+)\]\(buttonurl:(?://)?([^\s)]+?)(:same)?\)`)
+
+var markdownEscaper = strings.NewReplacer(
+	"_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[",
+)
+
+var markdownV2Escaper = strings.NewReplacer(
+	"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]",
+	"(", "\\(", ")", "\\)", "~", "\\~", "`", "\\`", ">", "\\>",
+	"#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=", "|", "\\|",
+	"{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+func Escape(s, parseMode string) string {
+	switch parseMode {
+	case Markdown:
+		return markdownEscaper.Replace(s)
+	case MarkdownV2:
+		return markdownV2Escaper.Replace(s)
+	case HTML:
+		return html.EscapeString(s)
+	}
+	return s
+}
+
+func Mention(u *bot.User, parseMode string) string {
+	return Link(u.FirstName, "tg://user?id="+strconv.FormatInt(u.ID, 10), parseMode)
+}
+
+func Link(text, url, parseMode string) string {
+	switch parseMode {
+	case Markdown:
+		return "[" + strings.ReplaceAll(text, "]", "") + "](" + url + ")"
+	case MarkdownV2:
+		return "[" + markdownV2Escaper.Replace(text) + "](" + url + ")"
+	case HTML:
+		return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(text) + "</a>"
+	}
+	return text
+}
+
+func Render(text, parseMode string, d Data) *Message {
+	return render(&doc{text: text}, parseMode, d)
+}
+
+func RenderEntities(text string, entities []bot.MessageEntity, d Data) *Message {
+	return render(&doc{text: text, entities: append([]bot.MessageEntity(nil), entities...)}, "", d)
+}
+
+func RenderStored(text string, entities json.RawMessage, fallback string, d Data) *Message {
+	if len(entities) == 0 || string(entities) == "null" {
+		return Render(text, fallback, d)
+	}
+	var parsed []bot.MessageEntity
+	json.Unmarshal(entities, &parsed)
+	return RenderEntities(text, parsed, d)
+}
+
+func EncodeEntities(entities []bot.MessageEntity) json.RawMessage {
+	if entities == nil {
+		entities = []bot.MessageEntity{}
+	}
+	data, _ := json.Marshal(entities)
+	return data
+}
+
+type filling struct {
+	text string
+	user *bot.User
+}
+
+func render(t *doc, parseMode string, d Data) *Message {
+	msg := &Message{ParseMode: parseMode, DisablePreview: true}
+
+	t.pickAlternative()
+	var source int64
+	if d.Chat != nil {
+		source = d.Chat.ID
+	}
+	msg.Markup = t.bindNotes(t.parseButtons(), d.Bot, source)
+
+	toggles := map[string]func(){
+		"{preview}": func() { msg.DisablePreview = false },
+		"{nonotif}": func() { msg.DisableNotification = true },
+		"{protect}": func() { msg.Protect = true },
+		"{rules}":   func() { msg.Rules = true },
+	}
+	fills := make(map[string]filling)
+	for tag, set := range toggles {
+		if strings.Contains(t.text, tag) {
+			set()
+			fills[tag] = filling{}
+		}
+	}
+
+	if strings.Contains(t.text, "{count}") && d.Count == 0 && d.Bot != nil && d.Chat != nil {
+		d.Count, _ = d.Bot.GetChatMemberCount(d.Chat.ID)
+	}
+	fills["{count}"] = filling{text: strconv.Itoa(d.Count)}
+
+	if d.Chat != nil {
+		fills["{chatname}"] = filling{text: Escape(d.Chat.Title, parseMode)}
+	}
+	if u := d.User; u != nil {
+		mention := filling{text: Mention(u, parseMode)}
+		if parseMode == "" {
+			mention.user = u
+		}
+		username := mention
+		if u.Username != "" {
+			username = filling{text: Escape("@"+u.Username, parseMode)}
+		}
+		id := filling{text: strconv.FormatInt(u.ID, 10)}
+
+		fills["{first}"] = filling{text: Escape(u.FirstName, parseMode)}
+		fills["{last}"] = filling{text: Escape(u.LastName, parseMode)}
+		fills["{fullname}"] = filling{text: Escape(strings.TrimSpace(u.FirstName+" "+u.LastName), parseMode)}
+		fills["{username}"] = username
+		fills["{mention}"] = mention
+		fills["{id}"] = id
+		fills["{firstname}"] = mention
+		fills["{userid}"] = id
+	}
+
+	t.fill(fills)
+	t.trimSpace()
+
+	if msg.Rules {
+		msg.addRulesButton(d)
+	}
+
+	msg.Text = t.text
+	msg.Entities = t.entities
+	return msg
+}
+
+func (m *Message) addRulesButton(d Data) {
+	if d.Bot == nil || d.Chat == nil {
+		return
+	}
+	url := d.Bot.StartLink("rules", d.Chat.ID)
+	if url == "" {
+		return
+	}
+	text := d.RulesButton
+	if text == "" {
+		text = "Rules"
+	}
+	if m.Markup == nil {
+		m.Markup = &bot.ReplyMarkup{}
+	}
+	m.Markup.InlineKeyboard = append(m.Markup.InlineKeyboard, []bot.InlineKeyboardButton{{Text: text, Url: url}})
+}
+
+func ParseButtons(text string) (string, *bot.ReplyMarkup) {
+	t := &doc{text: text}
+	return t.text, t.bindNotes(t.parseButtons(), nil, 0)
+}
+
+// NoteRef stands in for note names too long to fit in signed callback data.
+func NoteRef(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return noteRefPrefix + base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+// bindNotes gives #note buttons signed callback data naming the source chat
+// and note, so they keep working in pinned and welcome messages. Buttons that
+// can't be signed are dropped.
+func (t *doc) bindNotes(markup *bot.ReplyMarkup, b *bot.Bot, source int64) *bot.ReplyMarkup {
+	if len(t.notes) == 0 {
+		return markup
+	}
+	for i := len(t.notes) - 1; i >= 0; i-- {
+		n := t.notes[i]
+		row := markup.InlineKeyboard[n.row]
+		if b != nil && source != 0 {
+			chat := strconv.FormatInt(source, 10)
+			data := b.SignedCallback(NoteButtonPrefix, chat, n.name)
+			if data == "" {
+				data = b.SignedCallback(NoteButtonPrefix, chat, NoteRef(n.name))
+			}
+			if data != "" {
+				row[n.col].CallbackData = data
+				continue
+			}
+		}
+		markup.InlineKeyboard[n.row] = slices.Delete(row, n.col, n.col+1)
+	}
+	t.notes = nil
+
+	markup.InlineKeyboard = slices.DeleteFunc(markup.InlineKeyboard, func(row []bot.InlineKeyboardButton) bool {
+		return len(row) == 0
+	})
+	if len(markup.InlineKeyboard) == 0 {
+		return nil
+	}
+	return markup
+}
+
+func normalizeURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	return "https://" + url
+}
+
+type doc struct {
+	text     string
+	entities []bot.MessageEntity
+	notes    []noteButton
+}
+
+func (t *doc) replace(start, end int, s string) int {
+	uStart := bot.UTF16Len(t.text[:start])
+	uEnd := uStart + bot.UTF16Len(t.text[start:end])
+	uNew := uStart + bot.UTF16Len(s)
+
+	shift := func(p int) int {
+		switch {
+		case p <= uStart:
+			return p
+		case p >= uEnd:
+			return p + uNew - uEnd
+		}
+		return uNew
+	}
+
+	kept := t.entities[:0]
+	for _, e := range t.entities {
+		from, to := shift(e.Offset), shift(e.Offset+e.Length)
+		if to <= from {
+			continue
+		}
+		e.Offset, e.Length = from, to-from
+		kept = append(kept, e)
+	}
+	t.entities = kept
+	t.text = t.text[:start] + s + t.text[end:]
+	return uStart
+}
+
+func (t *doc) pickAlternative() {
+	var segs [][2]int
+	pos := 0
+	for {
+		i := strings.Index(t.text[pos:], "%%%")
+		if i < 0 {
+			segs = append(segs, [2]int{pos, len(t.text)})
+			break
+		}
+		segs = append(segs, [2]int{pos, pos + i})
+		pos += i + 3
+	}
+	if len(segs) < 2 {
+		return
+	}
+
+	seg := segs[rand.Intn(len(segs))]
+	t.replace(seg[1], len(t.text), "")
+	t.replace(0, seg[0], "")
+}
+
+func (t *doc) parseButtons() *bot.ReplyMarkup {
+	matches := buttonPattern.FindAllStringSubmatchIndex(t.text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var rows [][]bot.InlineKeyboardButton
+	for _, loc := range matches {
+		btn := bot.InlineKeyboardButton{Text: t.text[loc[2]:loc[3]]}
+		target := t.text[loc[4]:loc[5]]
+		name, isNote := strings.CutPrefix(target, "#")
+		if !isNote {
+			btn.Url = normalizeURL(target)
+		}
+
+		if loc[6] != -1 && len(rows) > 0 {
+			rows[len(rows)-1] = append(rows[len(rows)-1], btn)
+		} else {
+			rows = append(rows, []bot.InlineKeyboardButton{btn})
+		}
+		if isNote {
+			row := len(rows) - 1
+			t.notes = append(t.notes, noteButton{row: row, col: len(rows[row]) - 1, name: strings.ToLower(name)})
+		}
+	}
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		t.replace(matches[i][0], matches[i][1], "")
+	}
+	return &bot.ReplyMarkup{InlineKeyboard: rows}
+}
+
+func (t *doc) fill(fills map[string]filling) {
+	pos := 0
+	for {
+		i := strings.IndexByte(t.text[pos:], '{')
+		if i < 0 {
+			return
+		}
+		i += pos
+		j := strings.IndexByte(t.text[i:], '}')
+		if j < 0 {
+			return
+		}
+		j += i + 1
+
+		f, ok := fills[t.text[i:j]]
+		if !ok {
+			pos = i + 1
+			continue
+		}
+		off := t.replace(i, j, f.text)
+		if f.user != nil && f.text != "" {
+			t.entities = append(t.entities, bot.MessageEntity{
+				Type:   "text_mention",
+				Offset: off,
+				Length: bot.UTF16Len(f.text),
+				User:   f.user,
+			})
+		}
+		pos = i + len(f.text)
+	}
+}
+
+func (t *doc) trimSpace() {
+	trimmed := strings.TrimRightFunc(t.text, unicode.IsSpace)
+	t.replace(len(trimmed), len(t.text), "")
+	trimmed = strings.TrimLeftFunc(t.text, unicode.IsSpace)
+	t.replace(0, len(t.text)-len(trimmed), "")
+}
+
+func (m *Message) Apply(req map[string]any, field string) {
+	if field != "" {
+		req[field] = m.Text
+		if m.ParseMode != "" {
+			req["parse_mode"] = m.ParseMode
+		}
+		if len(m.Entities) > 0 {
+			if field == "caption" {
+				req["caption_entities"] = m.Entities
+			} else {
+				req["entities"] = m.Entities
+			}
+		}
+	}
+	if field == "text" && m.DisablePreview {
+		req["link_preview_options"] = map[string]any{"is_disabled": true}
+	}
+	if m.DisableNotification {
+		req["disable_notification"] = true
+	}
+	if m.Protect {
+		req["protect_content"] = true
+	}
+	if m.Markup != nil {
+		req["reply_markup"] = m.Markup
+	}
+}
+
+type media struct {
+	method  string
+	field   string
+	caption bool
+}
+
+var mediaTypes = map[string]media{
+	"photo":      {"sendPhoto", "photo", true},
+	"video":      {"sendVideo", "video", true},
+	"videonote":  {"sendVideoNote", "video_note", false},
+	"video_note": {"sendVideoNote", "video_note", false},
+	"animation":  {"sendAnimation", "animation", true},
+	"sticker":    {"sendSticker", "sticker", false},
+	"voice":      {"sendVoice", "voice", true},
+	"audio":      {"sendAudio", "audio", true},
+	"document":   {"sendDocument", "document", true},
+}
+
+func (m *Message) Send(b *bot.Bot, chatID int64, kind, fileID string) error {
+	_, err := m.Deliver(b, chatID, kind, fileID)
+	return err
+}
+
+func (m *Message) Deliver(b *bot.Bot, chatID int64, kind, fileID string) (*bot.Message, error) {
+	req := map[string]any{"chat_id": chatID}
+
+	md, ok := mediaTypes[kind]
+	if !ok || fileID == "" {
+		m.Apply(req, "text")
+		return b.RawMessage("sendMessage", req)
+	}
+
+	req[md.field] = fileID
+	if md.caption {
+		m.Apply(req, "caption")
+	} else {
+		m.Apply(req, "")
+	}
+	return b.RawMessage(md.method, req)
+}
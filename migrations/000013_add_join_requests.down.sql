@@ -0,0 +1 @@
+ALTER TABLE groups DROP COLUMN join_request_mode;
@@ -0,0 +1 @@
+ALTER TABLE groups ADD COLUMN join_request_mode VARCHAR(255) DEFAULT 'off';
@@ -0,0 +1,3 @@
+ALTER TABLE groups DROP COLUMN active;
+ALTER TABLE groups DROP COLUMN added_by;
+ALTER TABLE groups DROP COLUMN left_at;
@@ -0,0 +1,3 @@
+ALTER TABLE groups ADD COLUMN active BOOLEAN DEFAULT TRUE;
+ALTER TABLE groups ADD COLUMN added_by BIGINT;
+ALTER TABLE groups ADD COLUMN left_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+ALTER TABLE notes ADD COLUMN entities JSONB;
+ALTER TABLE filters ADD COLUMN entities JSONB;
@@ -0,0 +1,6 @@
+ALTER TABLE groups DROP COLUMN greeting_type;
+ALTER TABLE groups DROP COLUMN greeting_file_id;
+ALTER TABLE groups DROP COLUMN greeting_entities;
+ALTER TABLE groups DROP COLUMN goodbye_type;
+ALTER TABLE groups DROP COLUMN goodbye_file_id;
+ALTER TABLE groups DROP COLUMN goodbye_entities;
@@ -0,0 +1,6 @@
+ALTER TABLE groups ADD COLUMN greeting_type VARCHAR(255) DEFAULT 'text';
+ALTER TABLE groups ADD COLUMN greeting_file_id TEXT DEFAULT '';
+ALTER TABLE groups ADD COLUMN greeting_entities JSONB;
+ALTER TABLE groups ADD COLUMN goodbye_type VARCHAR(255) DEFAULT 'text';
+ALTER TABLE groups ADD COLUMN goodbye_file_id TEXT DEFAULT '';
+ALTER TABLE groups ADD COLUMN goodbye_entities JSONB;
@@ -0,0 +1,2 @@
+ALTER TABLE groups DROP COLUMN clean_welcome;
+ALTER TABLE groups DROP COLUMN auto_delete;
@@ -0,0 +1,2 @@
+ALTER TABLE groups ADD COLUMN clean_welcome BOOLEAN DEFAULT FALSE;
+ALTER TABLE groups ADD COLUMN auto_delete TEXT DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE groups ADD COLUMN clean_service TEXT DEFAULT '[]';
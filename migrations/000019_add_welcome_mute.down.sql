@@ -0,0 +1,2 @@
+ALTER TABLE groups DROP COLUMN welcome_mute;
+ALTER TABLE groups DROP COLUMN welcome_mute_time;
@@ -0,0 +1,2 @@
+ALTER TABLE groups ADD COLUMN welcome_mute VARCHAR(255) DEFAULT 'off';
+ALTER TABLE groups ADD COLUMN welcome_mute_time VARCHAR(255) DEFAULT '5m';
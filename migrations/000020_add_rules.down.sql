@@ -0,0 +1,4 @@
+ALTER TABLE groups DROP COLUMN rules;
+ALTER TABLE groups DROP COLUMN rules_entities;
+ALTER TABLE groups DROP COLUMN rules_private;
+ALTER TABLE groups DROP COLUMN rules_button;
@@ -0,0 +1,4 @@
+ALTER TABLE groups ADD COLUMN rules TEXT DEFAULT '';
+ALTER TABLE groups ADD COLUMN rules_entities JSONB;
+ALTER TABLE groups ADD COLUMN rules_private BOOLEAN DEFAULT FALSE;
+ALTER TABLE groups ADD COLUMN rules_button VARCHAR(255) DEFAULT 'Rules';
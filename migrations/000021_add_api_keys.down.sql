@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bans_group;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    key_hash TEXT NOT NULL UNIQUE,
+    name TEXT,
+    group_id BIGINT NOT NULL DEFAULT 0,
+    created_by BIGINT,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_group ON api_keys(group_id);
+CREATE INDEX idx_bans_group ON bans(group_id);
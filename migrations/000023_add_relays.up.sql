@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS relays (
+    id TEXT PRIMARY KEY,
+    group_id BIGINT NOT NULL,
+    name TEXT,
+    secret_hash TEXT NOT NULL,
+    thread_id BIGINT NOT NULL DEFAULT 0,
+    template TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_relays_group ON relays(group_id);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS log_routes (
+    group_id BIGINT NOT NULL,
+    category TEXT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    thread_id BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (group_id, category)
+);
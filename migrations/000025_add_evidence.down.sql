@@ -0,0 +1,2 @@
+ALTER TABLE groups DROP COLUMN evidence_categories;
+ALTER TABLE groups DROP COLUMN evidence_retention;
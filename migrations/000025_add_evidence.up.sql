@@ -0,0 +1,2 @@
+ALTER TABLE groups ADD COLUMN evidence_categories TEXT DEFAULT '[]';
+ALTER TABLE groups ADD COLUMN evidence_retention VARCHAR(32) DEFAULT 'off';